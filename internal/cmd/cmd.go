@@ -2,10 +2,14 @@ package cmd
 
 import (
 	"cmp"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"slices"
 	"sort"
+	"strings"
 	"text/template"
 )
 
@@ -13,30 +17,176 @@ const (
 	GofsDir = ".gofs"
 )
 
+// Exit codes returned by Cli.Exec.
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
 type Command struct {
 	// The command name, used to match the command line argument e.g. clitool <Name>.
 	Name string
 	// Short is used in the list of commands message e.g. "command does xyz"
 	Short string
 	// Long is used in the usage message from the help command e.g.:
-	// "Usage: <Name> [args]
+	// "usage: <Name> [flags] [args]
 	//
 	// "<Long>"
 	Long string
+	// Example is printed after the flags in the usage message.
+	Example string
+	// Args are the positional arguments accepted by the command, in order.
+	Args []Arg
+	// Flags registers the command flags on the flag set before parsing.
+	Flags func(fs *flag.FlagSet)
+	// FlagValues returns the accepted values of a flag, keyed by flag name.
+	// It is used for shell completion.
+	FlagValues map[string]func() []string
 	// Cmd is the function that is called when the command is matched.
-	Cmd func()
+	// It may be nil for a command group, which then prints its usage.
+	Cmd func(ctx *Context) error
+	// Commands are the subcommands of a command group e.g. clitool <Name> <sub>.
+	Commands []Command
+	// Hidden commands are not listed in the usage message or completions.
+	Hidden bool
+}
+
+// Arg describes a positional argument of a command.
+type Arg struct {
+	Name string
+	// Usage is a short description of the argument shown in the help message.
+	Usage string
+	// Required arguments must be given, otherwise the command fails with a
+	// usage error. Required arguments must come before optional ones.
+	Required bool
+	// Variadic collects all remaining arguments. Only valid on the last arg.
+	Variadic bool
+	// Check validates the argument value before the command is run.
+	Check func(v string) error
+	// Values returns the accepted values of the argument, used for shell
+	// completion.
+	Values func() []string
+}
+
+// Context is passed to a command when it is run. It holds the parsed flags
+// and arguments, and the streams the command should use instead of os.Std*.
+type Context struct {
+	Cli     *Cli
+	Command Command
+	// Path is the command names from the cli to the command e.g. ["db", "migrate"].
+	Path   []string
+	Flags  *flag.FlagSet
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	args []string
+}
+
+// Args returns all positional arguments.
+func (ctx *Context) Args() []string {
+	return ctx.args
+}
+
+// Arg returns the value of the named positional argument, or "" if it was
+// not given. For a variadic argument the values are joined with spaces, use
+// Rest to get them individually.
+func (ctx *Context) Arg(name string) string {
+	for i, a := range ctx.Command.Args {
+		if a.Name != name || i >= len(ctx.args) {
+			continue
+		}
+		if a.Variadic {
+			return strings.Join(ctx.args[i:], " ")
+		}
+		return ctx.args[i]
+	}
+	return ""
+}
+
+// Rest returns the values of the variadic argument.
+func (ctx *Context) Rest() []string {
+	n := len(ctx.Command.Args)
+	if n == 0 || !ctx.Command.Args[n-1].Variadic || len(ctx.args) < n {
+		return nil
+	}
+	return ctx.args[n-1:]
+}
+
+// String returns the value of a string flag.
+func (ctx *Context) String(name string) string {
+	v, _ := ctx.value(name).(string)
+	return v
+}
+
+// Bool returns the value of a bool flag.
+func (ctx *Context) Bool(name string) bool {
+	v, _ := ctx.value(name).(bool)
+	return v
+}
+
+// Int returns the value of an int flag.
+func (ctx *Context) Int(name string) int {
+	v, _ := ctx.value(name).(int)
+	return v
+}
+
+// IsSet reports whether the flag was given on the command line.
+func (ctx *Context) IsSet(name string) bool {
+	set := false
+	ctx.Flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func (ctx *Context) value(name string) any {
+	f := ctx.Flags.Lookup(name)
+	if f == nil {
+		panic("cmd: flag -" + name + " not defined by " + ctx.Command.Name)
+	}
+	g, ok := f.Value.(flag.Getter)
+	if !ok {
+		return nil
+	}
+	return g.Get()
+}
+
+// UsageError is returned when a command is called with invalid arguments.
+// The cli prints the usage line after the error and exits with ExitUsage.
+type UsageError struct {
+	Msg string
+}
+
+func (e *UsageError) Error() string {
+	return e.Msg
+}
+
+// Usagef returns a UsageError with a formatted message.
+func Usagef(format string, a ...any) error {
+	return &UsageError{Msg: fmt.Sprintf(format, a...)}
 }
 
 type Cli struct {
 	name     string
 	long     string
 	commands []Command
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
 }
 
 func New(name, long string) *Cli {
 	return &Cli{
-		name: name,
-		long: long,
+		name:   name,
+		long:   long,
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
 	}
 }
 
@@ -44,50 +194,271 @@ func (c *Cli) AddCmd(cmd Command) {
 	c.commands = append(c.commands, cmd)
 }
 
-func (c *Cli) Find(cmd string) (Command, bool) {
-	for _, v := range c.commands {
-		if v.Name == cmd {
+// Find returns the command at the path of command names e.g. Find("db", "migrate").
+func (c *Cli) Find(path ...string) (Command, bool) {
+	cmds := c.commands
+	var cmd Command
+	for _, name := range path {
+		var ok bool
+		cmd, ok = findCmd(cmds, name)
+		if !ok {
+			return Command{}, false
+		}
+		cmds = cmd.Commands
+	}
+	return cmd, len(path) > 0
+}
+
+func findCmd(cmds []Command, name string) (Command, bool) {
+	for _, v := range cmds {
+		if v.Name == name {
 			return v, true
 		}
 	}
 	return Command{}, false
 }
 
+// resolve walks args down the command tree and returns the deepest matching
+// command, the names leading to it and the remaining args.
+func (c *Cli) resolve(args []string) (Command, []string, []string) {
+	cmds := c.commands
+	var cmd Command
+	var path []string
+	for len(args) > 0 {
+		next, ok := findCmd(cmds, args[0])
+		if !ok {
+			break
+		}
+		cmd = next
+		path = append(path, next.Name)
+		cmds = next.Commands
+		args = args[1:]
+	}
+	return cmd, path, args
+}
+
+// Run runs the cli with the program arguments and exits with the status
+// code returned by Exec.
 func (c *Cli) Run() {
-	if len(os.Args) < 2 {
-		c.usage()
+	os.Exit(c.Exec(os.Args[1:]))
+}
+
+// Exec runs the command matched by args, which should not include the
+// program name, and returns the exit code.
+func (c *Cli) Exec(args []string) int {
+	if len(args) < 1 {
+		c.usage(c.Stdout)
+		return ExitOK
 	}
-	requestedCmd := os.Args[1]
 
 	// special case for help
-	if requestedCmd == "help" {
-		c.cmdHelp()
-		os.Exit(0)
+	if args[0] == "help" {
+		return c.cmdHelp(args[1:])
+	}
+
+	cm, path, rest := c.resolve(args)
+	if len(path) == 0 {
+		if p, ok := c.FindPlugin(args[0]); ok {
+			return c.runPlugin(p, args[1:])
+		}
+		fmt.Fprintf(c.Stderr, "%s: unknown command %q\n\n", c.name, args[0])
+		c.usage(c.Stderr)
+		return ExitUsage
 	}
+	name := c.name + " " + strings.Join(path, " ")
 
-	cm, ok := c.Find(requestedCmd)
+	if cm.Cmd == nil {
+		if len(rest) == 0 || rest[0] == "-h" || rest[0] == "-help" || rest[0] == "--help" {
+			c.groupUsage(c.Stdout, path, cm)
+			return ExitOK
+		}
+		fmt.Fprintf(c.Stderr, "%s: unknown command %q\n\n", name, rest[0])
+		c.groupUsage(c.Stderr, path, cm)
+		return ExitUsage
+	}
+
+	err := c.runCmd(cm, path, rest)
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, flag.ErrHelp):
+		c.help(c.Stdout, path, cm)
+		return ExitOK
+	}
+
+	fmt.Fprintf(c.Stderr, "%s: %s\n", name, err)
+	var usageErr *UsageError
+	if errors.As(err, &usageErr) {
+		fmt.Fprintf(c.Stderr, "usage: %s%s\n", name, cm.synopsis(cm.flagSet()))
+		fmt.Fprintf(c.Stderr, "Run '%s help %s' for details.\n", c.name, strings.Join(path, " "))
+		return ExitUsage
+	}
+	return ExitError
+}
+
+func (c *Cli) runCmd(cm Command, path, args []string) error {
+	fs := cm.flagSet()
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &UsageError{Msg: err.Error()}
+	}
+
+	if err := cm.checkArgs(fs.Args()); err != nil {
+		return err
+	}
+
+	return cm.Cmd(&Context{
+		Cli:     c,
+		Command: cm,
+		Path:    path,
+		Flags:   fs,
+		Stdin:   c.Stdin,
+		Stdout:  c.Stdout,
+		Stderr:  c.Stderr,
+		args:    fs.Args(),
+	})
+}
+
+func (c *Cli) cmdHelp(args []string) int {
+	if len(args) < 1 {
+		c.usage(c.Stdout)
+		return ExitOK
+	}
+	cmd, ok := c.Find(args...)
 	if !ok {
-		c.usage()
+		if p, ok := c.FindPlugin(args[0]); ok && len(args) == 1 {
+			return c.runPlugin(p, []string{"-help"})
+		}
+		fmt.Fprintf(c.Stderr, "%s help: unknown command %q\n\n", c.name, strings.Join(args, " "))
+		c.usage(c.Stderr)
+		return ExitUsage
+	}
+	if cmd.Cmd == nil {
+		c.groupUsage(c.Stdout, args, cmd)
+	} else {
+		c.help(c.Stdout, args, cmd)
+	}
+	return ExitOK
+}
+
+// flagSet returns a new flag set with the command flags registered. Parse
+// errors are returned rather than printed, the cli prints the usage.
+func (cmd Command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+	if cmd.Flags != nil {
+		cmd.Flags(fs)
 	}
-	cm.Cmd()
-	os.Exit(0)
+	return fs
 }
 
-func (c *Cli) cmdHelp() {
-	if len(os.Args) < 3 {
-		c.usage()
+func (cmd Command) checkArgs(args []string) error {
+	maxArgs := len(cmd.Args)
+	for i, a := range cmd.Args {
+		if a.Variadic {
+			maxArgs = -1
+		}
+		if i >= len(args) {
+			if a.Required {
+				return Usagef("missing %s", a.Name)
+			}
+			continue
+		}
+		if a.Check == nil {
+			continue
+		}
+		vals := args[i : i+1]
+		if a.Variadic {
+			vals = args[i:]
+		}
+		for _, v := range vals {
+			if err := a.Check(v); err != nil {
+				return Usagef("invalid %s %q: %s", a.Name, v, err)
+			}
+		}
+	}
+	if maxArgs >= 0 && len(args) > maxArgs {
+		return Usagef("too many arguments")
+	}
+	return nil
+}
+
+// synopsis returns the arguments part of the usage line e.g.
+// " [flags] <module-name> [dir]".
+func (cmd Command) synopsis(fs *flag.FlagSet) string {
+	s := ""
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		s += " [flags]"
+	}
+	for _, a := range cmd.Args {
+		name := a.Name
+		if a.Variadic {
+			name += "..."
+		}
+		if a.Required {
+			s += " <" + name + ">"
+		} else {
+			s += " [" + name + "]"
+		}
+	}
+	return s
+}
+
+// help writes the usage message of a command, generated from the declared
+// arguments and flags.
+func (c *Cli) help(w io.Writer, path []string, cmd Command) {
+	fs := cmd.flagSet()
+	fmt.Fprintf(w, "usage: %s %s%s\n\n", c.name, strings.Join(path, " "), cmd.synopsis(fs))
+	if cmd.Long != "" {
+		fmt.Fprint(w, strings.TrimRight(cmd.Long, "\n")+"\n\n")
+	}
+
+	if len(cmd.Args) > 0 {
+		fmt.Fprintln(w, "arguments:")
+		for _, a := range cmd.Args {
+			fmt.Fprintf(w, "  %s\n    %s\n", a.Name, a.Usage)
+		}
+		fmt.Fprintln(w)
+	}
+
+	hasFlags := false
+	fs.VisitAll(func(f *flag.Flag) {
+		if !hasFlags {
+			fmt.Fprintln(w, "flags:")
+			hasFlags = true
+		}
+		name, usage := flag.UnquoteUsage(f)
+		fmt.Fprintf(w, "  -%s", f.Name)
+		if name != "" {
+			fmt.Fprintf(w, " %s", name)
+		}
+		fmt.Fprintf(w, "\n    %s", strings.ReplaceAll(usage, "\n", "\n    "))
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			fmt.Fprintf(w, " (default %q)", f.DefValue)
+		}
+		fmt.Fprintln(w)
+	})
+	if hasFlags {
+		fmt.Fprintln(w)
+	}
+
+	if len(cmd.Commands) > 0 {
+		fmt.Fprintf(w, "The commands are:\n\n%s\n", listCmds(cmd.Commands))
+		fmt.Fprintf(w, "Use \"%s help %s <command>\" for more information about a command.\n\n", c.name, strings.Join(path, " "))
 	}
-	cmd, ok := c.Find(os.Args[2])
-	if ok {
-		fmt.Print(cmd.Long)
-		os.Exit(0)
+
+	if cmd.Example != "" {
+		fmt.Fprint(w, "example:\n"+strings.TrimRight(cmd.Example, "\n")+"\n\n")
 	}
-	c.usage()
 }
 
-// usage returns the usage message, always sorted by command name
-// always exits the program with status code 0
-func (c *Cli) usage() {
+// usage writes the usage message, always sorted by command name
+func (c *Cli) usage(w io.Writer) {
 	msg := `{{ .long }}
 
 See docs at https://gofs.dev
@@ -99,27 +470,79 @@ Usage:
 The commands are:
 
 {{ .commands }}
-Use "{{ .name }} help <command>" for more information about a command.
+{{ if .plugins }}The plugin commands found on PATH are:
+
+{{ .plugins }}
+{{ end }}Use "{{ .name }} help <command>" for more information about a command.
 
 `
 
+	m := map[string]any{
+		"long":     c.long,
+		"name":     c.name,
+		"commands": listCmds(c.commands),
+		"plugins":  listPlugins(c.Plugins()),
+	}
+	template.Must(template.New("usage").Parse(msg)).Execute(w, m)
+}
+
+// groupUsage writes the usage message of a command group, always sorted by
+// command name
+func (c *Cli) groupUsage(w io.Writer, path []string, group Command) {
+	msg := `{{ if .long }}{{ .long }}
+
+{{ end }}Usage:
+
+	{{ .name }} <command> [arguments]
+
+The commands are:
+
+{{ .commands }}
+Use "{{ .help }} <command>" for more information about a command.
+
+`
+
+	name := strings.Join(path, " ")
+	m := map[string]any{
+		"long":     strings.TrimRight(group.Long, "\n"),
+		"name":     c.name + " " + name,
+		"help":     c.name + " help " + name,
+		"commands": listCmds(group.Commands),
+	}
+	template.Must(template.New("usage").Parse(msg)).Execute(w, m)
+}
+
+// listCmds returns the aligned list of visible commands, sorted by command name
+func listCmds(cmds []Command) string {
+	cmds = visibleCmds(cmds)
+	if len(cmds) == 0 {
+		return ""
+	}
+
 	// we need the max length of the command names to align the help message
-	longestCmd := slices.MaxFunc(c.commands, func(i, j Command) int {
+	longestCmd := slices.MaxFunc(cmds, func(i, j Command) int {
 		return cmp.Compare(len(i.Name), len(j.Name))
 	})
 
 	maxCmdLen := len(longestCmd.Name)
 
-	sort.Slice(c.commands, func(i, j int) bool {
-		return c.commands[i].Name < c.commands[j].Name
+	sort.Slice(cmds, func(i, j int) bool {
+		return cmds[i].Name < cmds[j].Name
 	})
 
 	cmdList := ""
-	for _, c := range c.commands {
+	for _, c := range cmds {
 		cmdList += fmt.Sprintf("\t%-*s%s\n", maxCmdLen+8, c.Name, c.Short)
 	}
+	return cmdList
+}
 
-	m := map[string]any{"long": c.long, "name": c.name, "commands": cmdList}
-	template.Must(template.New("usage").Parse(msg)).Execute(os.Stdout, m)
-	os.Exit(0)
+func visibleCmds(cmds []Command) []Command {
+	visible := make([]Command, 0, len(cmds))
+	for _, c := range cmds {
+		if !c.Hidden {
+			visible = append(visible, c)
+		}
+	}
+	return visible
 }
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// run runs the gofs cli with args and stdin, and returns the exit code and
+// what was written to stdout and stderr.
+func run(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	c := *Gofs
+	c.Stdin = strings.NewReader(stdin)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	code := c.Exec(args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestExec(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{
+			name:   "no args",
+			code:   ExitOK,
+			stdout: "Usage:",
+		},
+		{
+			name:   "help",
+			args:   []string{"help", "init"},
+			code:   ExitOK,
+			stdout: "gofs init",
+		},
+		{
+			name:   "help flag",
+			args:   []string{"init", "-h"},
+			code:   ExitOK,
+			stdout: "gofs init",
+		},
+		{
+			name:   "unknown command",
+			args:   []string{"no-such-command"},
+			code:   ExitUsage,
+			stderr: `gofs: unknown command "no-such-command"`,
+		},
+		{
+			name:   "unknown help topic",
+			args:   []string{"help", "no-such-command"},
+			code:   ExitUsage,
+			stderr: `gofs help: unknown command "no-such-command"`,
+		},
+		{
+			name:   "unknown subcommand",
+			args:   []string{"editor", "no-such-command"},
+			code:   ExitUsage,
+			stderr: `gofs editor: unknown command "no-such-command"`,
+		},
+		{
+			name:   "unknown flag",
+			args:   []string{"init", "-no-such-flag"},
+			code:   ExitUsage,
+			stderr: "usage: gofs init",
+		},
+		{
+			name:   "missing argument",
+			args:   []string{"init"},
+			code:   ExitUsage,
+			stderr: "gofs init: missing module-name\nusage: gofs init",
+		},
+		{
+			name:   "invalid argument",
+			args:   []string{"init", "bad name"},
+			code:   ExitUsage,
+			stderr: "usage: gofs init",
+		},
+		{
+			name:   "unknown editor",
+			args:   []string{"init", "-editor=no-such-editor", "example.com/app"},
+			code:   ExitUsage,
+			stderr: `unknown editor "no-such-editor"`,
+		},
+		{
+			name:   "error",
+			args:   []string{"diff", t.TempDir()},
+			code:   ExitError,
+			stderr: "gofs diff: ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := run(t, "", tt.args...)
+			if code != tt.code {
+				t.Errorf("exit code %d, want %d\nstderr:\n%s", code, tt.code, stderr)
+			}
+			if !strings.Contains(stdout, tt.stdout) {
+				t.Errorf("stdout does not contain %q:\n%s", tt.stdout, stdout)
+			}
+			if !strings.Contains(stderr, tt.stderr) {
+				t.Errorf("stderr does not contain %q:\n%s", tt.stderr, stderr)
+			}
+		})
+	}
+}
+
+func TestExecInit(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app")
+	code, _, stderr := run(t, "", "init", "-no-hooks", "app", dir)
+	if code != ExitOK {
+		t.Fatalf("exit code %d, want %d\nstderr:\n%s", code, ExitOK, stderr)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "module app\n") {
+		t.Errorf("go.mod does not declare module app:\n%s", b)
+	}
+}
+
+func TestEditorsInManifest(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app")
+	code, _, stderr := run(t, "", "init", "-no-hooks", "-template=fs", "-editor=vscode,jetbrains", "example.com/app", dir)
+	if code != ExitOK {
+		t.Fatalf("init: exit code %d\nstderr:\n%s", code, stderr)
+	}
+	code, stdout, stderr := run(t, "", "diff", dir)
+	if code != ExitOK || !strings.Contains(stdout, "no differences") {
+		t.Fatalf("diff after init -editor: exit code %d\nstdout:\n%s\nstderr:\n%s", code, stdout, stderr)
+	}
+
+	code, _, stderr = run(t, "", "editor", "setup", "-editor=neovim", dir)
+	if code != ExitOK {
+		t.Fatalf("editor setup: exit code %d\nstderr:\n%s", code, stderr)
+	}
+	code, stdout, stderr = run(t, "", "diff", dir)
+	if code != ExitOK || !strings.Contains(stdout, "no differences") {
+		t.Fatalf("diff after editor setup: exit code %d\nstdout:\n%s\nstderr:\n%s", code, stdout, stderr)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".nvim.lua"), []byte("-- mine\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code, stdout, _ = run(t, "", "diff", "-stat", dir)
+	if code != ExitOK || !strings.Contains(stdout, "modified  .nvim.lua") {
+		t.Errorf("diff does not report the modified editor file:\n%s", stdout)
+	}
+}
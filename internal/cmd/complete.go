@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"flag"
+	"sort"
+	"strings"
+)
+
+// Complete returns the completion candidates for the last word in words.
+// words are the command line arguments without the program name, the last
+// word is the one being completed and may be empty.
+func (c *Cli) Complete(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur := words[len(words)-1]
+	prev := words[:len(words)-1]
+
+	// special case for help, complete the command path only
+	if len(prev) > 0 && prev[0] == "help" {
+		cmds := c.commands
+		for _, name := range prev[1:] {
+			cmd, ok := findCmd(cmds, name)
+			if !ok {
+				return nil
+			}
+			cmds = cmd.Commands
+		}
+		return matchPrefix(cmdNames(cmds), cur)
+	}
+
+	cm, path, rest := c.resolve(prev)
+	if len(path) == 0 {
+		if len(prev) > 0 {
+			return nil
+		}
+		names := append(cmdNames(c.commands), "help")
+		for _, p := range c.Plugins() {
+			names = append(names, p.Name)
+		}
+		return matchPrefix(names, cur)
+	}
+	fs := cm.flagSet()
+
+	// -flag=value
+	if name, value, ok := strings.Cut(cur, "="); ok && strings.HasPrefix(name, "-") {
+		var candidates []string
+		for _, v := range cm.flagValues(strings.TrimLeft(name, "-")) {
+			candidates = append(candidates, name+"="+v)
+		}
+		return matchPrefix(candidates, name+"="+value)
+	}
+
+	// -flag value
+	argc := 0
+	expectValue := ""
+	flagsDone := false
+	for _, w := range rest {
+		switch {
+		case expectValue != "":
+			expectValue = ""
+		case flagsDone || !strings.HasPrefix(w, "-") || w == "-":
+			flagsDone = true
+			argc++
+		case w == "--":
+			flagsDone = true
+		case !strings.Contains(w, "=") && !isBoolFlag(fs, strings.TrimLeft(w, "-")):
+			expectValue = strings.TrimLeft(w, "-")
+		}
+	}
+	if expectValue != "" {
+		return matchPrefix(cm.flagValues(expectValue), cur)
+	}
+
+	if !flagsDone && strings.HasPrefix(cur, "-") {
+		var candidates []string
+		fs.VisitAll(func(f *flag.Flag) {
+			candidates = append(candidates, "-"+f.Name)
+		})
+		return matchPrefix(candidates, cur)
+	}
+
+	if argc == 0 && len(cm.Commands) > 0 {
+		return matchPrefix(cmdNames(cm.Commands), cur)
+	}
+	for i, a := range cm.Args {
+		if (i == argc || a.Variadic && i <= argc) && a.Values != nil {
+			return matchPrefix(a.Values(), cur)
+		}
+	}
+	return nil
+}
+
+func (cmd Command) flagValues(name string) []string {
+	values, ok := cmd.FlagValues[name]
+	if !ok {
+		return nil
+	}
+	return values()
+}
+
+func isBoolFlag(fs *flag.FlagSet, name string) bool {
+	f := fs.Lookup(name)
+	if f == nil {
+		return true // unknown flags are assumed not to take a value
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func cmdNames(cmds []Command) []string {
+	var names []string
+	for _, c := range visibleCmds(cmds) {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func matchPrefix(candidates []string, prefix string) []string {
+	var matched []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			matched = append(matched, c)
+		}
+	}
+	sort.Strings(matched)
+	return matched
+}
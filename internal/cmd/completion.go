@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"text/template"
+)
+
+const completionUsage = `"completion" prints a shell completion script for gofs.
+
+The script completes commands, flags and flag values such as the template
+names accepted by "gofs init -template". Candidates are computed by gofs itself,
+so the script does not need to be regenerated after upgrading gofs.
+
+To load completions:
+
+  bash:
+    source <(gofs completion bash)
+
+  zsh:
+    source <(gofs completion zsh)
+
+  fish:
+    gofs completion fish | source
+
+Add the line to your shell profile to load the completions in every session.
+`
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for {{ .name }}
+_{{ .name }}() {
+	local line="${COMP_LINE:0:COMP_POINT}"
+	local -a words
+	read -ra words <<< "$line"
+	if [[ "$line" == *" " ]]; then
+		words+=("")
+	fi
+	local cur="${words[${#words[@]}-1]}"
+	local IFS=$'\n'
+	COMPREPLY=($({{ .name }} __complete -- "${words[@]:1}" 2>/dev/null))
+	# bash splits words at "=", only the part after it is replaced
+	if [[ "$cur" == *=* && "$COMP_WORDBREAKS" == *=* ]]; then
+		COMPREPLY=("${COMPREPLY[@]#*=}")
+	fi
+}
+complete -o default -F _{{ .name }} {{ .name }}
+`,
+	"zsh": `#compdef {{ .name }}
+
+_{{ .name }}() {
+	local -a candidates
+	candidates=("${(@f)$({{ .name }} __complete -- "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	candidates=(${candidates:#})
+	if (( ${#candidates} )); then
+		compadd -Q -- "${candidates[@]}"
+	else
+		_files
+	fi
+}
+
+if [ "$funcstack[1]" = "_{{ .name }}" ]; then
+	_{{ .name }} "$@"
+else
+	compdef _{{ .name }} {{ .name }}
+fi
+`,
+	"fish": `# fish completion for {{ .name }}
+function __{{ .name }}_complete
+	set -l args (commandline -opc)[2..-1] (commandline -ct)
+	{{ .name }} __complete -- $args 2>/dev/null
+end
+complete -c {{ .name }} -f -a '(__{{ .name }}_complete)'
+`,
+}
+
+func init() {
+	Gofs.AddCmd(Command{
+		Name:  "completion",
+		Short: "print a shell completion script",
+		Long:  completionUsage,
+		Args: []Arg{
+			{Name: "shell", Usage: "One of bash, zsh or fish.", Required: true, Check: checkShell, Values: shells},
+		},
+		Cmd: cmdCompletion,
+	})
+	Gofs.AddCmd(Command{
+		Name:   "__complete",
+		Short:  "print completion candidates for the command line",
+		Hidden: true,
+		Args: []Arg{
+			{Name: "words", Variadic: true},
+		},
+		Cmd: cmdComplete,
+	})
+}
+
+func shells() []string {
+	return []string{"bash", "fish", "zsh"}
+}
+
+func checkShell(v string) error {
+	if _, ok := completionScripts[v]; !ok {
+		return fmt.Errorf("supported shells are bash, zsh and fish")
+	}
+	return nil
+}
+
+func cmdCompletion(ctx *Context) error {
+	script := completionScripts[ctx.Arg("shell")]
+	m := map[string]any{"name": ctx.Cli.name}
+	return template.Must(template.New("completion").Parse(script)).Execute(ctx.Stdout, m)
+}
+
+func cmdComplete(ctx *Context) error {
+	for _, c := range ctx.Cli.Complete(ctx.Rest()) {
+		fmt.Fprintln(ctx.Stdout, c)
+	}
+	return nil
+}
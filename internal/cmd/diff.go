@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofs-cli/gofs/internal/diff"
+	"github.com/gofs-cli/gofs/internal/gen"
+	"github.com/gofs-cli/gofs/internal/project"
+)
+
+const diffUsage = `"diff" shows how a project drifted from its template.
+
+diff generates the template in memory with the module name, variables and
+features of the project and prints a unified diff of every file that differs
+from the working tree. Each file is marked with how it drifted, using the file
+hashes recorded when the project was generated:
+
+  modified   changed in the project
+  changed    changed in the template, unchanged in the project
+  deleted    deleted from the project
+  added      added to the template since the project was generated
+
+With a path, only the files in that file or directory are compared.
+`
+
+const diffExample = `  gofs diff
+  gofs diff internal/server
+  gofs diff -stat
+`
+
+func init() {
+	Gofs.AddCmd(Command{
+		Name:    "diff",
+		Short:   "show drift between the project and its template",
+		Long:    diffUsage,
+		Example: diffExample,
+		Args: []Arg{
+			{Name: "path", Usage: "File or directory of the project to compare, defaults to the current directory."},
+		},
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("template-dir", "", "Compare with the template in the `directory`.")
+			fs.Bool("stat", false, "Only list the files that differ.")
+		},
+		Cmd: cmdDiff,
+	})
+}
+
+// Drift of a project file from the template.
+const (
+	driftModified = "modified"
+	driftChanged  = "changed"
+	driftDeleted  = "deleted"
+	driftAdded    = "added"
+)
+
+func cmdDiff(ctx *Context) error {
+	target := ctx.Arg("path")
+	if target == "" {
+		target = "."
+	}
+	target, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	dir := target
+	if fi, err := os.Stat(target); err == nil && !fi.IsDir() {
+		dir = filepath.Dir(target)
+	}
+	proj, err := project.Find(dir)
+	if err != nil {
+		return err
+	}
+	prefix, err := filepath.Rel(proj.Root, target)
+	if err != nil {
+		return err
+	}
+	prefix = filepath.ToSlash(prefix)
+
+	manifest, err := gen.ReadManifest(proj.Root)
+	if err != nil {
+		return err
+	}
+	tmpl, _, err := manifestTemplate(ctx, manifest)
+	if err != nil {
+		return err
+	}
+	files, err := tmpl.Files()
+	if err != nil {
+		return err
+	}
+	parser, err := gen.NewProjectParser(proj.Root, proj.Module, manifest, files, tmpl.ModuleName)
+	if err != nil {
+		return err
+	}
+	out := gen.NewMemFS()
+	parser.Output = out
+	if _, err := parser.Parse(); err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, name := range out.Names() {
+		if prefix != "." && name != prefix && !strings.HasPrefix(name, prefix+"/") {
+			continue
+		}
+		generated, err := out.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		current, err := os.ReadFile(filepath.Join(proj.Root, filepath.FromSlash(name)))
+		var drift string
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			current = nil
+			drift = driftDeleted
+			if _, ok := manifest.Files[name]; !ok {
+				drift = driftAdded
+			}
+		case err != nil:
+			return err
+		case string(current) == string(generated):
+			continue
+		case manifest.Files[name] == gen.Hash(current):
+			drift = driftChanged
+		default:
+			drift = driftModified
+		}
+		counts[drift]++
+
+		if ctx.Bool("stat") {
+			fmt.Fprintf(ctx.Stdout, "  %-8s  %s\n", drift, name)
+			continue
+		}
+		fmt.Fprintf(ctx.Stdout, "%s %s\n", drift, name)
+		if diff.IsBinary(current) || diff.IsBinary(generated) {
+			fmt.Fprintln(ctx.Stdout, "binary files differ")
+			continue
+		}
+		fmt.Fprint(ctx.Stdout, diff.Unified(path.Join("template", name), name, generated, current))
+	}
+
+	var summary []string
+	for _, drift := range []string{driftModified, driftChanged, driftDeleted, driftAdded} {
+		if counts[drift] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[drift], drift))
+		}
+	}
+	if len(summary) == 0 {
+		fmt.Fprintf(ctx.Stdout, "no differences from template %s\n", tmpl.Name)
+		return nil
+	}
+	if ctx.Bool("stat") {
+		fmt.Fprintln(ctx.Stdout)
+	}
+	fmt.Fprintln(ctx.Stdout, strings.Join(summary, ", "))
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gofs-cli/gofs/internal/doctor"
+	"github.com/gofs-cli/gofs/internal/project"
+	"github.com/gofs-cli/gofs/internal/templates"
+)
+
+const doctorUsage = `"doctor" checks the local toolchain and the health of a gofs project.
+
+It checks the local go version against go.mod, and the presence and versions
+of templ, sqlc, air, bun, golangci-lint and docker used by the build scripts.
+
+Inside a project it also checks that the templ and sqlc generated code is up
+to date, and that .env sets the variables listed in .env.example. Outside a
+project the toolchain is checked against the template selected by -template.
+
+The templ code is compared with the code generated by the templ version gofs
+is built with. Code generated by another templ version, and the sqlc code,
+only get a warning when older than their sources, as modification times are
+not reliable.
+
+doctor exits with a non-zero status when a check fails.
+`
+
+func init() {
+	Gofs.AddCmd(Command{
+		Name:  "doctor",
+		Short: "check the toolchain and project health",
+		Long:  doctorUsage,
+		Args: []Arg{
+			{Name: "dir", Usage: "Project directory to check, defaults to the current directory."},
+		},
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("template", templates.Default, "Name of the `template` to check the toolchain against when not inside a project.")
+		},
+		FlagValues: map[string]func() []string{
+			"template": templates.Names,
+		},
+		Cmd: cmdDoctor,
+	})
+}
+
+func cmdDoctor(ctx *Context) error {
+	dir := ctx.Arg("dir")
+	if dir == "" {
+		dir = "."
+	}
+
+	var results []doctor.Result
+	proj, err := project.Find(dir)
+	switch {
+	case err == nil:
+		fmt.Fprintf(ctx.Stdout, "project %s (%s)\n\n", proj.Module, proj.Root)
+		results = append(doctor.Toolchain(os.DirFS(proj.Root)), doctor.Project(proj.Root)...)
+	case errors.Is(err, project.ErrNotFound):
+		name := ctx.String("template")
+		fmt.Fprintf(ctx.Stdout, "not inside a project, checking toolchain for template %s\n\n", name)
+		tmpl, err := templates.Lookup(name)
+		if err != nil {
+			return &UsageError{Msg: err.Error()}
+		}
+		files, err := tmpl.Files()
+		if err != nil {
+			return err
+		}
+		results = doctor.Toolchain(files)
+	default:
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		fmt.Fprintf(ctx.Stdout, "[%-4s] %-16s %s\n", r.Status, r.Name, r.Detail)
+		if r.Status != doctor.OK && r.Fix != "" {
+			fmt.Fprintf(ctx.Stdout, "       %-16s fix: %s\n", "", r.Fix)
+		}
+		if r.Status == doctor.Fail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
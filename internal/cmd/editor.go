@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gofs-cli/gofs/internal/editor"
+	"github.com/gofs-cli/gofs/internal/gen"
+	"github.com/gofs-cli/gofs/internal/project"
+)
+
+const editorUsage = `"editor" configures editors for a gofs project.`
+
+const editorSetupUsage = `"setup" writes the editor configuration of the project: gopls with the module
+as local import prefix, the templ and tailwind language servers and the
+recommended extensions or plugins.
+
+The editors are selected with -editor:
+
+  vscode     .vscode/settings.json and .vscode/extensions.json
+  neovim     .nvim.lua, loaded with the exrc option of Neovim 0.11
+  jetbrains  .idea/externalDependencies.xml, the required plugins
+
+An .editorconfig is written for every editor. The settings missing from
+existing VS Code files are merged into them, which drops their comments.
+Other existing files are kept.
+
+JetBrains IDEs only get the plugins. GoLand uses its own Go support instead
+of gopls, so its import grouping is not configured, and the templ and
+tailwind plugins start their language servers with their own settings.
+
+The editors are recorded in the manifest of a generated project, so diff
+and upgrade include their configuration. "gofs init -editor" configures the
+editors of the new project.
+`
+
+const editorSetupExample = `  gofs editor setup
+  gofs editor setup -editor=neovim,jetbrains
+  gofs editor setup -editor=vscode /path/to/project
+`
+
+func init() {
+	Gofs.AddCmd(Command{
+		Name:  "editor",
+		Short: "configure editors for the project",
+		Long:  editorUsage,
+		Commands: []Command{
+			{
+				Name:    "setup",
+				Short:   "write the editor configuration",
+				Long:    editorSetupUsage,
+				Example: editorSetupExample,
+				Args: []Arg{
+					{Name: "dir", Usage: "Project directory, defaults to the current directory."},
+				},
+				Flags: func(fs *flag.FlagSet) {
+					fs.Var(&listFlag{}, "editor", "Comma separated `editors` to configure, vscode by default.")
+				},
+				FlagValues: map[string]func() []string{
+					"editor": editor.Names,
+				},
+				Cmd: cmdEditorSetup,
+			},
+		},
+	})
+}
+
+func cmdEditorSetup(ctx *Context) error {
+	editors, _ := ctx.value("editor").([]string)
+	if len(editors) == 0 {
+		editors = []string{editor.VSCode}
+	}
+	if err := editor.Check(editors); err != nil {
+		return &UsageError{Msg: err.Error()}
+	}
+	dir := ctx.Arg("dir")
+	if dir == "" {
+		dir = "."
+	}
+	proj, err := project.Find(dir)
+	if err != nil {
+		return err
+	}
+	return setupEditors(ctx, proj.Root, proj.Module, editors)
+}
+
+// setupEditors writes the configuration of the editors to the project in dir
+// and prints what happened to every file.
+func setupEditors(ctx *Context, dir, module string, editors []string) error {
+	files, err := editor.Files(os.DirFS(dir), module, editors)
+	if err != nil {
+		return err
+	}
+	if err := recordEditors(dir, editors, files); err != nil {
+		return err
+	}
+	if err := editor.Write(dir, files); err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.Stdout, "configured %s\n", strings.Join(editors, ", "))
+	for _, f := range files {
+		fmt.Fprintf(ctx.Stdout, "  %-9s  %s\n", f.Status, f.Path)
+	}
+	return nil
+}
+
+// recordEditors adds the editors to the manifest of a generated project, so
+// diff and upgrade generate their configuration. The hashes of the files
+// that are created, or updated while unchanged since generation, are set
+// to their new content. Projects without a manifest are left as they are.
+func recordEditors(dir string, editors []string, files []editor.File) error {
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(gen.ManifestFile))); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	m, err := gen.ReadManifest(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range editors {
+		if !slices.Contains(m.Editors, name) {
+			m.Editors = append(m.Editors, name)
+		}
+	}
+	if m.Files == nil {
+		m.Files = map[string]string{}
+	}
+	for _, f := range files {
+		switch f.Status {
+		case editor.StatusCreate:
+			m.Files[f.Path] = gen.Hash(f.Content)
+		case editor.StatusUpdate:
+			old, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(f.Path)))
+			if err != nil {
+				return err
+			}
+			if m.Files[f.Path] == gen.Hash(old) {
+				m.Files[f.Path] = gen.Hash(f.Content)
+			}
+		}
+	}
+	return gen.WriteManifest(dir, m)
+}
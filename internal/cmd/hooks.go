@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofs-cli/gofs/internal/gen"
+	"github.com/gofs-cli/gofs/internal/prompt"
+)
+
+// runHooks runs the template hooks in the generated project at dir,
+// streaming their output. Network hooks are skipped with -offline and the
+// others run with GOPROXY=off, so go tool only uses cached modules. Hooks
+// whose command is not installed are skipped with a warning. A failing
+// optional hook is reported and the next hooks still run. On the first other
+// failing hook the remaining hooks are not run and are listed so they can be
+// run by hand.
+func runHooks(ctx *Context, sigCtx context.Context, dir string, hooks []gen.Hook) error {
+	// Unless is checked before any hook runs, so git init does not skip the
+	// initial commit
+	skip := make([]string, len(hooks))
+	for i, h := range hooks {
+		switch {
+		case h.Network && ctx.Bool("offline"):
+			skip[i] = "offline"
+		case h.Unless != "" && exists(filepath.Join(dir, filepath.FromSlash(h.Unless))):
+			skip[i] = h.Unless + " exists"
+		}
+	}
+
+	for i, h := range hooks {
+		if skip[i] == "" {
+			if _, err := exec.LookPath(h.Run[0]); err != nil {
+				skip[i] = h.Run[0] + " not found"
+			}
+		}
+		if skip[i] != "" {
+			fmt.Fprintf(ctx.Stdout, "skipping hook %s: %s\n", h.Name, skip[i])
+			continue
+		}
+
+		fmt.Fprintf(ctx.Stdout, "running hook %s: %s\n", h.Name, commandLine(h.Run))
+		c := exec.CommandContext(sigCtx, h.Run[0], h.Run[1:]...)
+		c.Dir = dir
+		c.Stdout = ctx.Stdout
+		c.Stderr = ctx.Stderr
+		if ctx.Bool("offline") {
+			c.Env = append(os.Environ(), "GOPROXY=off")
+		}
+		if err := c.Run(); err != nil {
+			if sigCtx.Err() != nil {
+				return fmt.Errorf("hook %s interrupted, the project was generated but its hooks did not finish", h.Name)
+			}
+			if h.Optional {
+				fmt.Fprintf(ctx.Stderr, "optional hook %s failed: %s: %s, continuing\n", h.Name, commandLine(h.Run), err)
+				continue
+			}
+			fmt.Fprintf(ctx.Stderr, "\nhook %s failed: %s: %s\n", h.Name, commandLine(h.Run), err)
+			fmt.Fprintf(ctx.Stderr, "the project was generated in %s, fix the error and run the remaining hooks by hand:\n", dir)
+			for j := i; j < len(hooks); j++ {
+				if skip[j] == "" {
+					fmt.Fprintf(ctx.Stderr, "  %s\n", commandLine(hooks[j].Run))
+				}
+			}
+			return fmt.Errorf("hook %s failed", h.Name)
+		}
+	}
+	return nil
+}
+
+// confirmHooks lists the commands of the hooks of a template that is not
+// built into gofs and asks whether to run them, as they run any command. p is
+// nil when init does not prompt, then the hooks are not run.
+func confirmHooks(ctx *Context, p *prompt.Prompter, hooks []gen.Hook) (bool, error) {
+	fmt.Fprintln(ctx.Stdout, "the template runs these hooks in the project:")
+	for _, h := range hooks {
+		fmt.Fprintf(ctx.Stdout, "  %s\n", commandLine(h.Run))
+	}
+	if p == nil {
+		fmt.Fprintln(ctx.Stdout, "not running the hooks of a template directory without -trust-hooks, run them by hand")
+		return false, nil
+	}
+	return p.Confirm("Run the hooks?", false)
+}
+
+// commandLine returns the command as typed in a shell.
+func commandLine(run []string) string {
+	args := make([]string, len(run))
+	for i, arg := range run {
+		args[i] = arg
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"$\\") {
+			args[i] = strconv.Quote(arg)
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+func exists(name string) bool {
+	_, err := os.Stat(name)
+	return !errors.Is(err, os.ErrNotExist)
+}
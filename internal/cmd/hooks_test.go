@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofs-cli/gofs/internal/gen"
+)
+
+// hookTemplate writes a template directory declaring the hooks and returns
+// its path.
+func hookTemplate(t *testing.T, hooks []gen.Hook) string {
+	t.Helper()
+	dir := t.TempDir()
+	config, err := json.Marshal(gen.Config{Hooks: hooks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"go.mod":       "module example.com/template\n\ngo 1.24\n",
+		gen.ConfigFile: string(config),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestTemplateDirHooks(t *testing.T) {
+	template := hookTemplate(t, []gen.Hook{
+		{Name: "version", Run: []string{"go", "version"}},
+	})
+	tests := []struct {
+		name  string
+		stdin string
+		flags []string
+		run   bool
+	}{
+		{name: "no terminal"},
+		{name: "trusted", flags: []string{"-trust-hooks"}, run: true},
+		{name: "confirmed", stdin: "y\n", flags: []string{"-interactive"}, run: true},
+		{name: "declined", stdin: "n\n", flags: []string{"-interactive"}},
+		{name: "default", stdin: "\n", flags: []string{"-interactive"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "app")
+			args := append([]string{"init", "-template-dir", template}, tt.flags...)
+			code, stdout, stderr := run(t, tt.stdin, append(args, "example.com/app", dir)...)
+			if code != ExitOK {
+				t.Fatalf("exit code %d\nstderr:\n%s", code, stderr)
+			}
+			if ran := strings.Contains(stdout, "running hook version"); ran != tt.run {
+				t.Errorf("hook ran %v, want %v\nstdout:\n%s", ran, tt.run, stdout)
+			}
+			if !tt.run && !strings.Contains(stdout, "  go version\n") {
+				t.Errorf("skipped hooks not listed:\n%s", stdout)
+			}
+		})
+	}
+}
+
+func TestOptionalHook(t *testing.T) {
+	tests := []struct {
+		name     string
+		optional bool
+		code     int
+		after    bool
+	}{
+		{name: "optional", optional: true, code: ExitOK, after: true},
+		{name: "required", code: ExitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := hookTemplate(t, []gen.Hook{
+				{Name: "fail", Run: []string{"go", "no-such-command"}, Optional: tt.optional},
+				{Name: "after", Run: []string{"go", "version"}},
+			})
+			dir := filepath.Join(t.TempDir(), "app")
+			code, stdout, stderr := run(t, "", "init", "-trust-hooks", "-template-dir", template, "example.com/app", dir)
+			if code != tt.code {
+				t.Errorf("exit code %d, want %d\nstderr:\n%s", code, tt.code, stderr)
+			}
+			if after := strings.Contains(stdout, "running hook after"); after != tt.after {
+				t.Errorf("hook after ran %v, want %v\nstdout:\n%s", after, tt.after, stdout)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+				t.Errorf("project not generated: %v", err)
+			}
+		})
+	}
+}
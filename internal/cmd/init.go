@@ -1,108 +1,580 @@
 package cmd
 
 import (
-	"embed"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	goversion "go/version"
+	"maps"
 	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
 
-	azureTemplate "github.com/gofs-cli/azure-app-template"
-	defaultTemplate "github.com/gofs-cli/template"
+	"golang.org/x/mod/module"
 
+	"github.com/gofs-cli/gofs/internal/editor"
 	"github.com/gofs-cli/gofs/internal/gen"
-	fsTemplate "github.com/gofs-cli/gofs/templates/fs-app"
+	"github.com/gofs-cli/gofs/internal/prompt"
+	"github.com/gofs-cli/gofs/internal/templates"
+	"github.com/gofs-cli/gofs/internal/version"
 )
 
-const initUsage = `usage: gofs init [module-name] [dir]
-
-"init" initializes a new module in the specified directory.
+const initUsage = `"init" initializes a new module in the specified directory.
 If no directory is specified, the current directory is used.
 
 The module name should be a go module name, e.g. "github.com/user/module".
 
-flags:
-  -template
-    Name of the template to use for the project. By default this will ues the basic bare bones template.
+With -dry-run nothing is written. init prints the files it would create, the
+files that get the module path rewritten and the files that conflict with
+existing files, as JSON with -json.
+
+When the module name is missing and stdin is a terminal, init asks for the
+module name, directory, template and variables and shows a summary before generating.
+
+Templates may declare variables such as the app name, port or org, set with
+-var key=value or prompted for. Values are substituted as they are, so a
+variable may restrict them with a pattern, e.g. to exclude quotes. Run
+"gofs template show <name>" to see them.
+
+Templates may also declare optional features, e.g. a local postgres database.
+The default features are generated unless excluded with -without, the others
+are added with -with. The files of an excluded feature are left out, along
+with its imports, go.mod requirements, docker compose services and
+.env.example variables.
+
+When the directory already contains a go module, the template is merged into
+it so existing services can adopt the template incrementally. The module name
+has to match the existing module. New files are added, identical files are
+skipped and go.mod and go.sum get the template requirements. Other files that
+differ from the template get conflict markers, or with -conflict=sidecar the
+template version is written next to them with a .gofs-new suffix.
 
-    Available names:
-      - azure
-          This template creates an app suitable for deployment to azure apps and expects azure auth tokens from Entra ID
-      - fs
-          This template creates an app for general apps using daisyUI/Tailwind/htmx/alpinejs/Go.
+The go.mod of the template moves to the new module, along with the
+requirements, replace and tool directives referring to the template module.
+Replace directives pointing to directories outside the template are dropped.
+With -align-go a go directive requiring a newer go than installed is lowered
+to the installed go version, and a toolchain directive naming a newer go is
+dropped. Older go directives are kept.
 
+-editor writes the configuration of the editors, e.g. vscode or neovim, see
+"gofs help editor setup".
 
-Example:
-  gofs init mymodule
+After generating, init runs the hooks the template declares in the new
+project, e.g. go mod tidy, templ generate and git init, showing their output.
+-offline skips the hooks that download dependencies and runs the others with
+GOPROXY=off, -no-hooks skips all of them. Hooks are not run when merging into an existing module.
+The hooks of a -template-dir template run any command, so init lists them and
+asks before running them, or runs them without asking with -trust-hooks.
+Without a terminal they are only listed.
+
+Files a template keeps for its authors are listed in a .gofsignore file at the
+template root, in gitignore syntax, and not generated. Other template files
+can be left out with -exclude, using slash separated patterns as in
+path.Match, where a trailing /** matches everything in a directory. Go imports
+of the packages left out are removed.
+
+Run "gofs template list" to see the available templates. A template that is
+not built into gofs, e.g. a git checkout of a company template, can be used
+with -template-dir. Its module name is read from the go.mod in the directory.
+The files its .gitignore lists, .env files, node_modules and the tmp and bin
+directories are not copied.
+`
+
+const initExample = `  gofs init mymodule
   gofs init mymodule /path/to/dir
   gofs init -template=azure mymodule
   gofs init -template=azure mymodule /path/to/dir
-
+  gofs init -template=fs -var app_name="My App" -var port=3000 mymodule
+  gofs init -template=fs -without=postgres,tracing mymodule
+  gofs init -exclude='.github/**,docker/*' mymodule
+  gofs init -offline mymodule
+  gofs init -align-go mymodule
+  gofs init -editor=vscode,neovim mymodule
+  gofs init -template-dir=/path/to/template mymodule
+  gofs init -dry-run -json mymodule /path/to/dir
 `
 
 func init() {
 	Gofs.AddCmd(Command{
-		Name:  "init",
-		Short: "initialize a new gofs mdodule",
-		Long:  initUsage,
-		Cmd:   cmdInit,
+		Name:    "init",
+		Short:   "initialize a new gofs mdodule",
+		Long:    initUsage,
+		Example: initExample,
+		Args: []Arg{
+			{Name: "module-name", Usage: "Go module name of the new project, prompted for when missing on a terminal.", Check: module.CheckImportPath},
+			{Name: "dir", Usage: "Directory to create the project in, defaults to the current directory."},
+		},
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("template", templates.Default, "Name of the `template` to use for the project. By default this will use the basic bare bones template.")
+			fs.String("template-dir", "", "Use the template in the `directory` instead of a built in template.")
+			fs.Var(varsFlag{}, "var", "Set a template variable as `key=value`, may be repeated.")
+			fs.Var(&listFlag{}, "with", "Comma separated `features` to add to the default features.")
+			fs.Var(&listFlag{}, "without", "Comma separated `features` to leave out.")
+			fs.Var(&listFlag{}, "exclude", "Comma separated `patterns` of template files not to generate, e.g. .github/**.")
+			fs.Bool("interactive", false, "Prompt for missing arguments even when stdin is not a terminal.")
+			fs.String("conflict", gen.ConflictMarkers, "How to write files conflicting with an existing module, `markers` or sidecar.")
+			fs.Var(&listFlag{}, "editor", "Comma separated `editors` to configure, see \"gofs help editor setup\".")
+			fs.Bool("align-go", false, "Lower the go directive of go.mod to the installed go version when newer.")
+			fs.Bool("no-hooks", false, "Do not run the template hooks after generating.")
+			fs.Bool("offline", false, "Skip the template hooks that download dependencies.")
+			fs.Bool("trust-hooks", false, "Run the hooks of a -template-dir template without asking.")
+			fs.Bool("dry-run", false, "Print the files that would be generated without writing anything.")
+			fs.Bool("json", false, "Print the -dry-run plan as JSON.")
+		},
+		FlagValues: map[string]func() []string{
+			"template": templates.Names,
+			"with":     featureNames,
+			"without":  featureNames,
+			"conflict": func() []string { return []string{gen.ConflictMarkers, gen.ConflictSidecar} },
+			"editor":   editor.Names,
+		},
+		Cmd: cmdInit,
 	})
 }
 
-func cmdInit() {
-	var template string
-	fs := flag.NewFlagSet("init", flag.ExitOnError)
-	fs.StringVar(&template, "template", "default", "the template to use for the generated project")
+// initOptions are the choices made by flags, arguments or prompts.
+type initOptions struct {
+	moduleName string
+	dir        string
+	template   string
+	vars       map[string]string
+	// features are the selected template features, nil until chosen.
+	features []string
+}
+
+// varsFlag collects repeated -var key=value flags.
+type varsFlag map[string]string
+
+func (v varsFlag) String() string {
+	var pairs []string
+	for k, val := range v {
+		pairs = append(pairs, k+"="+val)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (v varsFlag) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return errors.New("expected key=value")
+	}
+	v[key] = value
+	return nil
+}
+
+func (v varsFlag) Get() any {
+	return map[string]string(v)
+}
+
+// listFlag collects comma separated values of repeated flags.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+func (l *listFlag) Get() any {
+	return []string(*l)
+}
+
+// featureNames returns the features of the built in templates.
+func featureNames() []string {
+	var names []string
+	for _, t := range templates.List() {
+		files, err := t.Files()
+		if err != nil {
+			continue
+		}
+		config, err := gen.LoadConfig(files)
+		if err != nil {
+			continue
+		}
+		for _, f := range config.Features {
+			if !slices.Contains(names, f.Name) {
+				names = append(names, f.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func cmdInit(ctx *Context) error {
+	opts := initOptions{
+		moduleName: ctx.Arg("module-name"),
+		dir:        ctx.Arg("dir"),
+		template:   ctx.String("template"),
+		vars:       map[string]string{},
+	}
+	given, _ := ctx.value("var").(map[string]string)
+	maps.Copy(opts.vars, given)
+
+	if ctx.Bool("json") && !ctx.Bool("dry-run") {
+		return Usagef("-json requires -dry-run")
+	}
+	if c := ctx.String("conflict"); c != gen.ConflictMarkers && c != gen.ConflictSidecar {
+		return Usagef("-conflict must be %s or %s", gen.ConflictMarkers, gen.ConflictSidecar)
+	}
+	editors, _ := ctx.value("editor").([]string)
+	if err := editor.Check(editors); err != nil {
+		return &UsageError{Msg: err.Error()}
+	}
+
+	// fail on an unknown template before prompting
+	if _, err := selectTemplate(ctx, opts.template); err != nil {
+		return err
+	}
+
+	var p *prompt.Prompter
+	if opts.moduleName == "" {
+		if !ctx.Bool("interactive") && !prompt.IsTerminal(ctx.Stdin) {
+			return Usagef("missing module-name")
+		}
+		p = prompt.New(ctx.Stdin, ctx.Stdout)
+		if err := promptInit(ctx, p, &opts); err != nil {
+			return err
+		}
+	}
+
+	if opts.dir == "" {
+		var err error
+		opts.dir, err = os.Getwd()
+		if err != nil {
+			return fmt.Errorf("error getting current directory: %w", err)
+		}
+	}
+
+	tmpl, err := selectTemplate(ctx, opts.template)
+	if err != nil {
+		return err
+	}
+	files, err := tmpl.Files()
+	if err != nil {
+		return err
+	}
+	config, err := gen.LoadConfig(files)
+	if err != nil {
+		return err
+	}
+
+	builtin := gen.BuiltinVars(opts.moduleName)
+	if p != nil {
+		if err := promptVars(p, config, builtin, &opts); err != nil {
+			return err
+		}
+		if !ctx.IsSet("with") && !ctx.IsSet("without") && len(config.Features) > 0 {
+			if err := promptFeatures(p, config, &opts); err != nil {
+				return err
+			}
+		}
+	}
+	vars, err := config.Resolve(opts.vars, builtin)
+	if err != nil {
+		return &UsageError{Msg: err.Error()}
+	}
+	if opts.features == nil {
+		with, _ := ctx.value("with").([]string)
+		without, _ := ctx.value("without").([]string)
+		opts.features, err = config.SelectFeatures(with, without)
+		if err != nil {
+			return &UsageError{Msg: err.Error()}
+		}
+	}
+
+	if p != nil {
+		ok, err := confirmInit(ctx, p, opts, tmpl, vars)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("aborted")
+		}
+	}
+
+	parser, err := gen.NewParser(opts.dir, tmpl.ModuleName, opts.moduleName, files)
+	if err != nil {
+		return fmt.Errorf("error creating parser: %w", err)
+	}
+	parser.Vars = vars
+	parser.Features = opts.features
+	parser.Exclude, _ = ctx.value("exclude").([]string)
+	parser.Conflict = ctx.String("conflict")
+	parser.Editors = editors
+	parser.Manifest = &gen.Manifest{
+		Template:    tmpl.Name,
+		GofsVersion: version.Gofs(),
+	}
+	if dir := ctx.String("template-dir"); dir != "" {
+		if parser.Manifest.TemplateDir, err = filepath.Abs(dir); err != nil {
+			return err
+		}
+	} else {
+		parser.Manifest.TemplateVersion = version.Template(tmpl.ModuleName).Version
+	}
+	if ctx.Bool("align-go") {
+		if parser.GoVersion, err = localGoVersion(); err != nil {
+			return err
+		}
+	}
+	if ctx.Bool("dry-run") {
+		return printPlan(ctx, parser)
+	}
+	fmt.Fprintln(ctx.Stdout, "using template: ", tmpl.Name)
+
+	// stop on interrupt, leaving the directory as it was
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	results, err := parser.ParseContext(sigCtx)
+	if err != nil {
+		if sigCtx.Err() != nil {
+			return errors.New("interrupted, nothing was generated")
+		}
+		return err
+	}
+	if parser.Merge {
+		printMerge(ctx, "merged into existing module "+parser.NewModName, results)
+	}
+	if len(editors) > 0 {
+		fmt.Fprintf(ctx.Stdout, "configured %s\n", strings.Join(editors, ", "))
+	}
+	hooks := parser.Config.Hooks
+	if parser.Merge || ctx.Bool("no-hooks") || len(hooks) == 0 {
+		return nil
+	}
+	// hooks of templates not built into gofs run commands from anywhere
+	if ctx.String("template-dir") != "" && !ctx.Bool("trust-hooks") {
+		if p == nil && (ctx.Bool("interactive") || prompt.IsTerminal(ctx.Stdin)) {
+			p = prompt.New(ctx.Stdin, ctx.Stdout)
+		}
+		ok, err := confirmHooks(ctx, p, hooks)
+		if err != nil || !ok {
+			return err
+		}
+	}
+	return runHooks(ctx, sigCtx, opts.dir, hooks)
+}
+
+// localGoVersion returns the version of the installed go as written in the go
+// directive of go.mod, e.g. 1.25.5. go runs outside of any module with
+// GOTOOLCHAIN=local, so a go.mod requiring a newer go neither fails the
+// command nor downloads another toolchain.
+func localGoVersion() (string, error) {
+	cmd := exec.Command("go", "env", "GOVERSION")
+	cmd.Dir = os.TempDir()
+	cmd.Env = append(os.Environ(), "GOTOOLCHAIN=local")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("-align-go: error running go: %w", err)
+	}
+	// GOVERSION may be followed by the enabled experiments
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 || !goversion.IsValid(fields[0]) {
+		return "", fmt.Errorf("-align-go: cannot use go version %q", strings.TrimSpace(string(out)))
+	}
+	return strings.TrimPrefix(fields[0], "go"), nil
+}
+
+// printMerge prints the files changed by merging into an existing module and
+// how many files got each status.
+func printMerge(ctx *Context, title string, results []gen.Result) {
+	fmt.Fprintln(ctx.Stdout, title)
+	counts := map[string]int{}
+	changed := 0
+	for _, r := range results {
+		counts[r.Status]++
+		if r.Status == gen.StatusIdentical || r.Status == gen.StatusUnchanged {
+			continue
+		}
+		if changed == 0 {
+			fmt.Fprintln(ctx.Stdout)
+		}
+		changed++
+		fmt.Fprintf(ctx.Stdout, "  %-9s  %s\n", r.Status, r.Path)
+	}
+	var summary []string
+	for _, status := range []string{gen.StatusCreate, gen.StatusMerged, gen.StatusConflict, gen.StatusSidecar, gen.StatusIdentical, gen.StatusUnchanged} {
+		if counts[status] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	fmt.Fprintf(ctx.Stdout, "\n%s\n", strings.Join(summary, ", "))
+	if counts[gen.StatusConflict] > 0 {
+		fmt.Fprintln(ctx.Stdout, "resolve the conflict markers in the conflicting files")
+	}
+	if counts[gen.StatusSidecar] > 0 {
+		fmt.Fprintf(ctx.Stdout, "review the %s files and merge them by hand\n", gen.SidecarSuffix)
+	}
+}
+
+// printPlan prints the files the parser would generate.
+func printPlan(ctx *Context, parser *gen.Parser) error {
+	plan, err := parser.Plan()
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("json") {
+		enc := json.NewEncoder(ctx.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	}
+
+	fmt.Fprintf(ctx.Stdout, "dry run: %d files for module %s in %s\n", len(plan.Files), plan.Module, plan.Dir)
+	fmt.Fprintf(ctx.Stdout, "template module %s\n", plan.TemplateModule)
+	if len(plan.Features) > 0 {
+		fmt.Fprintf(ctx.Stdout, "features %s\n", strings.Join(plan.Features, ", "))
+	}
+	if len(plan.Hooks) > 0 {
+		fmt.Fprintf(ctx.Stdout, "hooks %s\n", strings.Join(plan.Hooks, ", "))
+	}
+	fmt.Fprintln(ctx.Stdout)
+	width := 0
+	for _, f := range plan.Files {
+		width = max(width, len(f.Path))
+	}
+	for _, f := range plan.Files {
+		var changes []string
+		if f.Rewrite != "" {
+			changes = append(changes, "rewrite "+f.Rewrite)
+		}
+		if len(f.Vars) > 0 {
+			changes = append(changes, "vars "+strings.Join(f.Vars, ","))
+		}
+		line := fmt.Sprintf("  %-9s  %-*s  %s", f.Status, width, f.Path, strings.Join(changes, ", "))
+		fmt.Fprintln(ctx.Stdout, strings.TrimRight(line, " "))
+	}
+	n := len(plan.Conflicts())
+	switch {
+	case n > 0 && plan.Merge:
+		fmt.Fprintf(ctx.Stdout, "\n%d file(s) conflict with existing files and would get conflict markers\n", n)
+	case n > 0:
+		fmt.Fprintf(ctx.Stdout, "\n%d file(s) conflict with existing files and would be overwritten\n", n)
+	}
+	return nil
+}
+
+// selectTemplate returns the template selected by -template or -template-dir.
+func selectTemplate(ctx *Context, name string) (templates.Template, error) {
+	dir := ctx.String("template-dir")
+	if dir == "" {
+		t, err := templates.Lookup(name)
+		if err != nil {
+			return templates.Template{}, &UsageError{Msg: err.Error()}
+		}
+		return t, nil
+	}
+	if ctx.IsSet("template") {
+		return templates.Template{}, Usagef("-template and -template-dir are mutually exclusive")
+	}
+	return templates.FromDir(dir)
+}
 
-	args := os.Args[2:] // skip program name and command name
+// promptInit asks for the module name and the options not given on the
+// command line.
+func promptInit(ctx *Context, p *prompt.Prompter, opts *initOptions) error {
 	var err error
-	err = fs.Parse(args)
+	opts.moduleName, err = p.String("Module name (e.g. github.com/user/module)", "", module.CheckImportPath)
 	if err != nil {
-		os.Stderr.WriteString("init: error parsing flags: " + err.Error() + "\n")
-		os.Exit(1)
-	}
-
-	fmt.Println("using template: ", template)
-	moduleName := ""
-	dir := ""
-
-	switch fs.NArg() {
-	case 0:
-		fmt.Println("init: missing module name")
-		fmt.Print(initUsage)
-		return
-	case 1:
-		moduleName = fs.Arg(0)
-		dir, err = os.Getwd()
+		return err
+	}
+
+	if opts.dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("error getting current directory: %w", err)
+		}
+		opts.dir, err = p.String("Directory", wd, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !ctx.IsSet("template") && !ctx.IsSet("template-dir") {
+		var options []prompt.Option
+		for _, t := range templates.List() {
+			options = append(options, prompt.Option{Value: t.Name, Description: t.Description})
+		}
+		opts.template, err = p.Select("Template", options, opts.template)
 		if err != nil {
-			os.Stderr.WriteString("init: error getting current directory: " + err.Error() + "\n")
-			os.Exit(1)
-		}
-	case 2:
-		moduleName = fs.Arg(0)
-		dir = fs.Arg(1)
-	default:
-		fmt.Println("init: too many arguments")
-		fmt.Print(initUsage)
-		return
-	}
-
-	var selectedTemplate embed.FS
-	var templateModuleName string
-	switch template {
-	case "fse":
-		templateModuleName = fsTemplate.ModuleName
-		selectedTemplate = fsTemplate.Folder
-	case "azure":
-		templateModuleName = azureTemplate.ModuleName
-		selectedTemplate = azureTemplate.Folder
-	default:
-		templateModuleName = defaultTemplate.ModuleName
-		selectedTemplate = defaultTemplate.Folder
-	}
-	parser, err := gen.NewParser(dir, templateModuleName, moduleName, selectedTemplate)
+			return err
+		}
+	}
+	return nil
+}
+
+// promptVars asks for the template variables not given with -var.
+func promptVars(p *prompt.Prompter, config *gen.Config, builtin map[string]string, opts *initOptions) error {
+	for _, v := range config.Variables {
+		if _, ok := opts.vars[v.Name]; ok {
+			continue
+		}
+		label := v.Name
+		if v.Description != "" {
+			label = v.Description + " (" + v.Name + ")"
+		}
+		value, err := p.String(label, v.DefaultValue(builtin), v.Check)
+		if err != nil {
+			return err
+		}
+		opts.vars[v.Name] = value
+	}
+	return nil
+}
+
+// promptFeatures asks for the template features to generate.
+func promptFeatures(p *prompt.Prompter, config *gen.Config, opts *initOptions) error {
+	var options []prompt.Option
+	for _, f := range config.Features {
+		options = append(options, prompt.Option{Value: f.Name, Description: f.Description})
+	}
+	var err error
+	opts.features, err = p.MultiSelect("Features", options, config.DefaultFeatures())
+	return err
+}
+
+// confirmInit shows a summary of the choices and asks for confirmation.
+func confirmInit(ctx *Context, p *prompt.Prompter, opts initOptions, tmpl templates.Template, vars map[string]string) (bool, error) {
+	dir, err := filepath.Abs(opts.dir)
 	if err != nil {
-		os.Stderr.WriteString("init: error creating parser: " + err.Error() + "\n")
-		os.Exit(1)
+		return false, err
+	}
+	template := tmpl.Name
+	if dir := ctx.String("template-dir"); dir != "" {
+		template = dir
+	}
+	width := len("directory")
+	for name := range vars {
+		width = max(width, len(name))
+	}
+	fmt.Fprintf(ctx.Stdout, "\nSummary:\n")
+	fmt.Fprintf(ctx.Stdout, "  %-*s  %s\n", width, "module", opts.moduleName)
+	fmt.Fprintf(ctx.Stdout, "  %-*s  %s\n", width, "directory", dir)
+	fmt.Fprintf(ctx.Stdout, "  %-*s  %s\n", width, "template", template)
+	if len(opts.features) > 0 {
+		fmt.Fprintf(ctx.Stdout, "  %-*s  %s\n", width, "features", strings.Join(opts.features, ", "))
+	}
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		fmt.Fprintf(ctx.Stdout, "  %-*s  %s\n", width, name, vars[name])
 	}
-	parser.Parse()
+	fmt.Fprintln(ctx.Stdout)
+	return p.Confirm("Create project?", true)
 }
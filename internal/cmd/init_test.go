@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInitWizard(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app")
+	answers := []string{
+		"bad name",       // module name, asked again
+		"example.com/w1", // module name
+		dir,              // directory
+		"fs",             // template
+		"My App",         // app_name
+		"",               // description
+		"",               // package_name
+		"9090",           // port
+		"Acme \"Inc\"",   // org, asked again
+		"Acme",           // org
+		"none",           // features
+		"",               // create project
+	}
+	code, stdout, stderr := run(t, strings.Join(answers, "\n")+"\n", "init", "-interactive", "-no-hooks")
+	if code != ExitOK {
+		t.Fatalf("exit code %d, want %d\nstdout:\n%s\nstderr:\n%s", code, ExitOK, stdout, stderr)
+	}
+	for _, want := range []string{
+		"Module name (e.g. github.com/user/module): ",
+		"malformed import path",
+		"Summary:",
+		"app_name      My App",
+		"port          9090",
+		"org           Acme",
+		`invalid value "Acme \"Inc\"" for org`,
+		"Create project? [Y/n]: ",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout does not contain %q:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "  features") {
+		t.Errorf("summary lists features although none were chosen:\n%s", stdout)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "module example.com/w1\n") {
+		t.Errorf("go.mod does not declare module example.com/w1:\n%s", b)
+	}
+	b, err = os.ReadFile(filepath.Join(dir, "internal/config/config.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "9090") {
+		t.Errorf("config.go does not use port 9090:\n%s", b)
+	}
+	b, err = os.ReadFile(filepath.Join(dir, "Dockerfile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `vendor="Acme"`) {
+		t.Errorf("Dockerfile does not use org Acme:\n%s", b)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".github/workflows")); err == nil {
+		t.Error("ci feature generated although none were chosen")
+	}
+}
+
+func TestInitWizardDeclined(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app")
+	answers := []string{"example.com/w1", dir, "default", "n"}
+	code, _, stderr := run(t, strings.Join(answers, "\n")+"\n", "init", "-interactive", "-no-hooks")
+	if code != ExitError || !strings.Contains(stderr, "aborted") {
+		t.Errorf("exit code %d, want %d with aborted\nstderr:\n%s", code, ExitError, stderr)
+	}
+	if _, err := os.Stat(dir); err == nil {
+		t.Error("declined init created the directory")
+	}
+}
+
+func TestInitWizardEndOfInput(t *testing.T) {
+	code, _, stderr := run(t, "example.com/w1\n", "init", "-interactive", "-no-hooks")
+	if code != ExitError || !strings.Contains(stderr, "aborted") {
+		t.Errorf("exit code %d, want %d with aborted\nstderr:\n%s", code, ExitError, stderr)
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/gofs-cli/gofs/internal/project"
+)
+
+// Plugin is an external command found on PATH, named <cli>-<name> e.g. a
+// gofs-deploy binary is run by "gofs deploy".
+type Plugin struct {
+	Name string
+	Path string
+}
+
+func (c *Cli) pluginPrefix() string {
+	return c.name + "-"
+}
+
+// Plugins returns the plugins found on PATH, sorted by name. Plugins with the
+// same name as a built in command are ignored, and the first plugin on PATH
+// wins when several have the same name.
+func (c *Cli) Plugins() []Plugin {
+	seen := map[string]bool{}
+	var plugins []Plugin
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name, ok := strings.CutPrefix(e.Name(), c.pluginPrefix())
+			if !ok || e.IsDir() {
+				continue
+			}
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if name == "" || seen[name] || name == "help" {
+				continue
+			}
+			if _, builtin := c.Find(name); builtin {
+				continue
+			}
+			path, err := exec.LookPath(filepath.Join(dir, e.Name()))
+			if err != nil {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: path})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name < plugins[j].Name
+	})
+	return plugins
+}
+
+// FindPlugin looks up the plugin for the command name on PATH.
+func (c *Cli) FindPlugin(name string) (Plugin, bool) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return Plugin{}, false
+	}
+	path, err := exec.LookPath(c.pluginPrefix() + name)
+	if err != nil {
+		return Plugin{}, false
+	}
+	return Plugin{Name: name, Path: path}, true
+}
+
+// runPlugin runs the plugin with the args and returns its exit code. The
+// environment is forwarded with the project root and module name added when
+// run inside a go module:
+//
+//	GOFS_BIN            path of the gofs executable
+//	GOFS_PROJECT_ROOT   directory containing go.mod
+//	GOFS_MODULE         module path declared in go.mod
+func (c *Cli) runPlugin(p Plugin, args []string) int {
+	env := os.Environ()
+	envPrefix := strings.ToUpper(c.name) + "_"
+	if exe, err := os.Executable(); err == nil {
+		env = append(env, envPrefix+"BIN="+exe)
+	}
+	if proj, err := project.Find("."); err == nil {
+		env = append(env,
+			envPrefix+"PROJECT_ROOT="+proj.Root,
+			envPrefix+"MODULE="+proj.Module,
+		)
+	}
+
+	cmd := exec.Command(p.Path, args...)
+	cmd.Env = env
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = c.Stdout
+	cmd.Stderr = c.Stderr
+	err := cmd.Run()
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	fmt.Fprintf(c.Stderr, "%s %s: %s\n", c.name, p.Name, err)
+	return ExitError
+}
+
+// listPlugins returns the aligned list of plugins, sorted by name
+func listPlugins(plugins []Plugin) string {
+	maxLen := 0
+	for _, p := range plugins {
+		maxLen = max(maxLen, len(p.Name))
+	}
+	list := ""
+	for _, p := range plugins {
+		list += fmt.Sprintf("\t%-*s%s\n", maxLen+8, p.Name, p.Path)
+	}
+	return list
+}
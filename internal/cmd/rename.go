@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+
+	"golang.org/x/mod/module"
+
+	"github.com/gofs-cli/gofs/internal/gen"
+	"github.com/gofs-cli/gofs/internal/project"
+)
+
+const renameUsage = `"rename" changes the module path of a project.
+
+rename rewrites the module path in go.mod, the imports of the go and templ
+files and the gopls formatting.local setting in .vscode/settings.json. It is
+also replaced in Dockerfiles, Makefiles, YAML and TOML files such as
+sqlc.yaml, .golangci.yml and GitHub workflows, and Markdown files. The
+node_modules, tmp and bin directories at the root of the project are
+skipped. Every changed file is listed. The files are only written once all of
+them are rewritten.
+
+Run "go tool templ generate" afterwards if the templ generated code is not
+committed.
+`
+
+const renameExample = `  gofs rename github.com/org/newname
+  gofs rename -dry-run github.com/org/newname /path/to/project
+`
+
+func init() {
+	Gofs.AddCmd(Command{
+		Name:    "rename",
+		Short:   "change the module path of the project",
+		Long:    renameUsage,
+		Example: renameExample,
+		Args: []Arg{
+			{Name: "new-module-path", Usage: "New go module path of the project.", Required: true, Check: module.CheckImportPath},
+			{Name: "dir", Usage: "Project directory, defaults to the current directory."},
+		},
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("dry-run", false, "Print the files that would change without writing anything.")
+		},
+		Cmd: cmdRename,
+	})
+}
+
+func cmdRename(ctx *Context) error {
+	dir := ctx.Arg("dir")
+	if dir == "" {
+		dir = "."
+	}
+	proj, err := project.Find(dir)
+	if err != nil {
+		return err
+	}
+	newModule := ctx.Arg("new-module-path")
+	if newModule == proj.Module {
+		return Usagef("module is already %s", newModule)
+	}
+
+	parser := &gen.Parser{
+		DirPath:        proj.Root,
+		CurrentModName: proj.Module,
+		NewModName:     newModule,
+	}
+	var changed []gen.File
+	if ctx.Bool("dry-run") {
+		changed, err = parser.RenameFiles()
+	} else {
+		changed, err = parser.Rename()
+	}
+	if err != nil {
+		return err
+	}
+
+	verb := "renamed"
+	if ctx.Bool("dry-run") {
+		verb = "dry run: would rename"
+	}
+	fmt.Fprintf(ctx.Stdout, "%s %s to %s in %s\n\n", verb, proj.Module, newModule, proj.Root)
+	width := 0
+	for _, f := range changed {
+		width = max(width, len(f.Rewrite))
+	}
+	for _, f := range changed {
+		fmt.Fprintf(ctx.Stdout, "  %-*s  %s\n", width, f.Rewrite, f.Path)
+	}
+	fmt.Fprintf(ctx.Stdout, "\n%d file(s) changed\n", len(changed))
+	return nil
+}
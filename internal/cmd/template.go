@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/gofs-cli/gofs/internal/gen"
+	"github.com/gofs-cli/gofs/internal/templates"
+	"github.com/gofs-cli/gofs/internal/version"
+)
+
+const templateUsage = `"template" lists and describes the templates available to "gofs init".`
+
+const templateShowUsage = `"show" prints the details of a template and the files it generates.`
+
+func init() {
+	Gofs.AddCmd(Command{
+		Name:  "template",
+		Short: "list and inspect project templates",
+		Long:  templateUsage,
+		Commands: []Command{
+			{
+				Name:  "list",
+				Short: "list the available templates",
+				Long:  `"list" prints the name and description of every available template.`,
+				Cmd:   cmdTemplateList,
+			},
+			{
+				Name:  "show",
+				Short: "show the details of a template",
+				Long:  templateShowUsage,
+				Args: []Arg{
+					{Name: "name", Usage: "Name of the template.", Required: true, Values: templates.Names},
+				},
+				Cmd: cmdTemplateShow,
+			},
+		},
+	})
+}
+
+func cmdTemplateList(ctx *Context) error {
+	list := templates.List()
+	width := 0
+	for _, t := range list {
+		width = max(width, len(t.Name))
+	}
+	for _, t := range list {
+		fmt.Fprintf(ctx.Stdout, "%-*s  %s\n", width, t.Name, t.Description)
+	}
+	return nil
+}
+
+func cmdTemplateShow(ctx *Context) error {
+	t, err := templates.Lookup(ctx.Arg("name"))
+	if err != nil {
+		return &UsageError{Msg: err.Error()}
+	}
+	v := version.Template(t.ModuleName)
+
+	fmt.Fprintf(ctx.Stdout, "name         %s\n", t.Name)
+	fmt.Fprintf(ctx.Stdout, "description  %s\n", t.Description)
+	fmt.Fprintf(ctx.Stdout, "module       %s\n", t.ModuleName)
+	fmt.Fprintf(ctx.Stdout, "version      %s\n", v.Version)
+	fmt.Fprintf(ctx.Stdout, "root         %s\n", t.Root)
+
+	files, err := t.Files()
+	if err != nil {
+		return err
+	}
+	config, err := gen.LoadConfig(files)
+	if err != nil {
+		return err
+	}
+	if len(config.Variables) > 0 {
+		fmt.Fprintln(ctx.Stdout, "variables:")
+		for _, v := range config.Variables {
+			def := "required"
+			if v.Default != "" {
+				def = "default " + strconv.Quote(v.Default)
+			}
+			fmt.Fprintf(ctx.Stdout, "  %s (%s)\n    %s\n", v.Name, def, v.Description)
+			if v.Pattern != "" {
+				fmt.Fprintf(ctx.Stdout, "    matches %s\n", v.Pattern)
+			}
+		}
+	}
+	if len(config.Features) > 0 {
+		fmt.Fprintln(ctx.Stdout, "features:")
+		for _, f := range config.Features {
+			def := "optional"
+			if f.Default {
+				def = "default"
+			}
+			fmt.Fprintf(ctx.Stdout, "  %s (%s)\n    %s\n", f.Name, def, f.Description)
+		}
+	}
+	if len(config.Hooks) > 0 {
+		fmt.Fprintln(ctx.Stdout, "hooks:")
+		for _, h := range config.Hooks {
+			var notes []string
+			if h.Network {
+				notes = append(notes, "network")
+			}
+			if h.Optional {
+				notes = append(notes, "optional")
+			}
+			note := ""
+			if len(notes) > 0 {
+				note = " (" + strings.Join(notes, ", ") + ")"
+			}
+			fmt.Fprintf(ctx.Stdout, "  %s%s\n    %s\n", h.Name, note, strings.Join(h.Run, " "))
+		}
+	}
+
+	ignore, err := gen.LoadIgnore(files)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(ctx.Stdout, "files:")
+	return fs.WalkDir(files, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// folder.go embeds the template and is not generated
+		if !d.IsDir() && path != "folder.go" && path != gen.ConfigFile && path != gen.IgnoreFile && !ignore.Ignores(path) {
+			fmt.Fprintf(ctx.Stdout, "  %s\n", path)
+		}
+		return nil
+	})
+}
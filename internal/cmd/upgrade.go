@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/gofs-cli/gofs/internal/gen"
+	"github.com/gofs-cli/gofs/internal/project"
+	"github.com/gofs-cli/gofs/internal/templates"
+	"github.com/gofs-cli/gofs/internal/version"
+)
+
+const upgradeUsage = `"upgrade" applies the changes made to the template since the project was
+generated.
+
+init records the template under .gofs/snapshot. upgrade generates both the
+snapshot and the current template with the module name, variables and
+features of the project, and merges the changes between the two into the
+project files. Files changed in both the project and the template get conflict
+markers, or with -conflict=sidecar the template version is written next to
+them with a .gofs-new suffix. Files deleted from the project stay deleted,
+unless the template changed them: the template version is then written with
+a .gofs-new suffix. Files removed from the template are listed but kept.
+
+The template is the built in template the project was generated from, or the
+directory it was read from. Use -template-dir to upgrade from another
+checkout of the template.
+`
+
+const upgradeExample = `  gofs upgrade
+  gofs upgrade -dry-run /path/to/project
+  gofs upgrade -template-dir=/path/to/template
+`
+
+func init() {
+	Gofs.AddCmd(Command{
+		Name:    "upgrade",
+		Short:   "apply template changes to the project",
+		Long:    upgradeUsage,
+		Example: upgradeExample,
+		Args: []Arg{
+			{Name: "dir", Usage: "Project directory, defaults to the current directory."},
+		},
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("template-dir", "", "Upgrade to the template in the `directory`.")
+			fs.String("conflict", gen.ConflictMarkers, "How to write conflicting files, `markers` or sidecar.")
+			fs.Bool("dry-run", false, "Print the files that would change without writing anything.")
+			fs.Bool("json", false, "Print the -dry-run plan as JSON.")
+		},
+		FlagValues: map[string]func() []string{
+			"conflict": func() []string { return []string{gen.ConflictMarkers, gen.ConflictSidecar} },
+		},
+		Cmd: cmdUpgrade,
+	})
+}
+
+func cmdUpgrade(ctx *Context) error {
+	if ctx.Bool("json") && !ctx.Bool("dry-run") {
+		return Usagef("-json requires -dry-run")
+	}
+	if c := ctx.String("conflict"); c != gen.ConflictMarkers && c != gen.ConflictSidecar {
+		return Usagef("-conflict must be %s or %s", gen.ConflictMarkers, gen.ConflictSidecar)
+	}
+	dir := ctx.Arg("dir")
+	if dir == "" {
+		dir = "."
+	}
+	proj, err := project.Find(dir)
+	if err != nil {
+		return err
+	}
+	manifest, err := gen.ReadManifest(proj.Root)
+	if err != nil {
+		return err
+	}
+
+	tmpl, templateDir, err := manifestTemplate(ctx, manifest)
+	if err != nil {
+		return err
+	}
+	files, err := tmpl.Files()
+	if err != nil {
+		return err
+	}
+
+	parser, err := gen.NewUpgradeParser(proj.Root, proj.Module, manifest, files, tmpl.ModuleName)
+	if err != nil {
+		return err
+	}
+	parser.Conflict = ctx.String("conflict")
+	parser.Manifest.Template = tmpl.Name
+	parser.Manifest.TemplateDir = templateDir
+	parser.Manifest.TemplateVersion = ""
+	if templateDir == "" {
+		parser.Manifest.TemplateVersion = version.Template(tmpl.ModuleName).Version
+	}
+	parser.Manifest.GofsVersion = version.Gofs()
+	if ctx.Bool("dry-run") {
+		return printPlan(ctx, parser)
+	}
+
+	removed, err := parser.Removed()
+	if err != nil {
+		return err
+	}
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	results, err := parser.ParseContext(sigCtx)
+	if err != nil {
+		if sigCtx.Err() != nil {
+			return errors.New("interrupted, nothing was changed")
+		}
+		return err
+	}
+	printMerge(ctx, fmt.Sprintf("upgraded %s to template %s", proj.Module, tmpl.Name), results)
+	if len(removed) > 0 {
+		fmt.Fprintln(ctx.Stdout, "\nremoved from the template, delete them if unused:")
+		for _, name := range removed {
+			fmt.Fprintf(ctx.Stdout, "  %s\n", name)
+		}
+	}
+	return nil
+}
+
+// manifestTemplate returns the template the project was generated from, or
+// the template in the directory given with -template-dir, and the absolute
+// template directory.
+func manifestTemplate(ctx *Context, manifest *gen.Manifest) (templates.Template, string, error) {
+	dir := ctx.String("template-dir")
+	if dir == "" {
+		dir = manifest.TemplateDir
+	}
+	if dir == "" {
+		tmpl, err := templates.Lookup(manifest.Template)
+		return tmpl, "", err
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return templates.Template{}, "", err
+	}
+	tmpl, err := templates.FromDir(dir)
+	return tmpl, dir, err
+}
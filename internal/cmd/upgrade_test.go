@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofs-cli/gofs/internal/gen"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	template := t.TempDir()
+	writeFiles(t, template, map[string]string{
+		"go.mod":    "module example.com/template\n\ngo 1.24\n",
+		"keep.txt":  "keep\n",
+		"notes.txt": "notes\n",
+		"edit.txt":  "edit\n",
+	})
+	dir := filepath.Join(t.TempDir(), "app")
+	if code, _, stderr := run(t, "", "init", "-template-dir", template, "example.com/app", dir); code != ExitOK {
+		t.Fatalf("init exit code %d\nstderr:\n%s", code, stderr)
+	}
+
+	for _, name := range []string{"keep.txt", "notes.txt"} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFiles(t, template, map[string]string{
+		"notes.txt": "notes v2\n",
+		"edit.txt":  "edit v2\n",
+	})
+
+	code, stdout, stderr := run(t, "", "upgrade", "-dry-run", dir)
+	if code != ExitOK {
+		t.Fatalf("upgrade -dry-run exit code %d\nstderr:\n%s", code, stderr)
+	}
+	for _, want := range []string{"unchanged  keep.txt", "sidecar    notes.txt" + gen.SidecarSuffix} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("dry run does not contain %q:\n%s", want, stdout)
+		}
+	}
+
+	code, stdout, stderr = run(t, "", "upgrade", dir)
+	if code != ExitOK {
+		t.Fatalf("upgrade exit code %d\nstderr:\n%s", code, stderr)
+	}
+	for _, want := range []string{"merged     edit.txt", "sidecar    notes.txt" + gen.SidecarSuffix} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout does not contain %q:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "create") {
+		t.Errorf("upgrade created files:\n%s", stdout)
+	}
+	for _, name := range []string{"keep.txt", "notes.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("deleted %s recreated", name)
+		}
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "notes.txt"+gen.SidecarSuffix))
+	if err != nil || string(b) != "notes v2\n" {
+		t.Errorf("notes.txt%s = %q, %v, want the template version", gen.SidecarSuffix, b, err)
+	}
+	b, err = os.ReadFile(filepath.Join(dir, "edit.txt"))
+	if err != nil || string(b) != "edit v2\n" {
+		t.Errorf("edit.txt = %q, %v, want the template version", b, err)
+	}
+}
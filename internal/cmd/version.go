@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/gofs-cli/gofs/internal/templates"
+	"github.com/gofs-cli/gofs/internal/version"
+)
+
+const versionUsage = `"version" prints the gofs version, the go toolchain it was built with and
+the versions of the templates embedded in it.
+
+Projects generated by the same gofs version use the same template versions.
+`
+
+func init() {
+	Gofs.AddCmd(Command{
+		Name:  "version",
+		Short: "print gofs and template versions",
+		Long:  versionUsage,
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("json", false, "Print the versions as JSON.")
+		},
+		Cmd: cmdVersion,
+	})
+}
+
+func cmdVersion(ctx *Context) error {
+	info := version.Get()
+	for _, t := range templates.List() {
+		m := version.Template(t.ModuleName)
+		m.Name = t.Name
+		info.Templates = append(info.Templates, m)
+	}
+
+	if ctx.Bool("json") {
+		enc := json.NewEncoder(ctx.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+
+	fmt.Fprintf(ctx.Stdout, "gofs version %s\n", info.Version)
+	if info.Revision != "" {
+		modified := ""
+		if info.Modified {
+			modified = " (modified)"
+		}
+		fmt.Fprintf(ctx.Stdout, "revision %s %s%s\n", info.Revision, info.Time, modified)
+	}
+	fmt.Fprintf(ctx.Stdout, "go version %s %s\n", info.GoVersion, info.Platform)
+	fmt.Fprintln(ctx.Stdout, "templates:")
+	width := 0
+	for _, t := range info.Templates {
+		width = max(width, len(t.Name))
+	}
+	for _, t := range info.Templates {
+		replace := ""
+		if t.Replace != "" {
+			replace = " => " + t.Replace
+		}
+		fmt.Fprintf(ctx.Stdout, "  %-*s  %s %s%s\n", width, t.Name, t.Path, t.Version, replace)
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+// Package diff compares and merges text files line by line.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxEdits bounds the work of comparing files, files needing more edits are
+// treated as having no lines in common.
+const maxEdits = 2048
+
+// Lines splits b into lines, keeping the line endings.
+func Lines(b []byte) []string {
+	var lines []string
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			lines = append(lines, string(b))
+			break
+		}
+		lines = append(lines, string(b[:i+1]))
+		b = b[i+1:]
+	}
+	return lines
+}
+
+// IsBinary reports whether b looks like a binary file rather than text.
+func IsBinary(b []byte) bool {
+	return bytes.IndexByte(b, 0) >= 0
+}
+
+// match returns the index pairs of the lines of a and b in a longest common
+// subsequence, in increasing order.
+func match(a, b []string) [][2]int {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var pairs [][2]int
+	for i := range pre {
+		pairs = append(pairs, [2]int{i, i})
+	}
+	for _, p := range myers(a[pre:len(a)-suf], b[pre:len(b)-suf]) {
+		pairs = append(pairs, [2]int{p[0] + pre, p[1] + pre})
+	}
+	for i := suf; i > 0; i-- {
+		pairs = append(pairs, [2]int{len(a) - i, len(b) - i})
+	}
+	return pairs
+}
+
+// myers implements the O(ND) difference algorithm of Eugene W. Myers.
+func myers(a, b []string) [][2]int {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return nil
+	}
+	limit := min(n+m, maxEdits)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace holds v for k in [-d, d] after every step d
+	var trace [][]int
+	x, y := 0, 0
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y = x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+	}
+	if !found {
+		return nil
+	}
+
+	var pairs [][2]int
+	x, y = n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			pairs = append(pairs, [2]int{x, y})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		pairs = append(pairs, [2]int{x, y})
+	}
+	slices.Reverse(pairs)
+	return pairs
+}
+
+// Labels name the sides of a merge in conflict markers.
+type Labels struct {
+	Ours   string
+	Base   string
+	Theirs string
+}
+
+// Merge merges the changes from base to theirs into ours. Changes to
+// different lines are combined, while lines changed on both sides are
+// marked as a conflict in the result. Without a base, every difference
+// between ours and theirs is a conflict. It reports whether there were
+// conflicts.
+func Merge(base, ours, theirs []byte, labels Labels) ([]byte, bool) {
+	if base == nil {
+		return merge2(Lines(ours), Lines(theirs), labels)
+	}
+	return merge3(Lines(base), Lines(ours), Lines(theirs), labels)
+}
+
+func merge2(ours, theirs []string, labels Labels) ([]byte, bool) {
+	var out bytes.Buffer
+	conflict := false
+	a, b := 0, 0
+	for _, p := range append(match(ours, theirs), [2]int{len(ours), len(theirs)}) {
+		if p[0] > a || p[1] > b {
+			writeConflict(&out, ours[a:p[0]], nil, theirs[b:p[1]], labels)
+			conflict = true
+		}
+		if p[0] < len(ours) {
+			out.WriteString(ours[p[0]])
+		}
+		a, b = p[0]+1, p[1]+1
+	}
+	return out.Bytes(), conflict
+}
+
+func merge3(base, ours, theirs []string, labels Labels) ([]byte, bool) {
+	toOurs := matchIndex(base, ours)
+	toTheirs := matchIndex(base, theirs)
+
+	var out bytes.Buffer
+	conflict := false
+	o, a, b := 0, 0, 0
+	for {
+		// copy the lines unchanged on both sides
+		for o < len(base) && toOurs[o] == a && toTheirs[o] == b {
+			out.WriteString(base[o])
+			o, a, b = o+1, a+1, b+1
+		}
+		if o == len(base) && a == len(ours) && b == len(theirs) {
+			break
+		}
+
+		// find the next line unchanged on both sides
+		o2, a2, b2 := len(base), len(ours), len(theirs)
+		for i := o; i < len(base); i++ {
+			if toOurs[i] >= 0 && toTheirs[i] >= 0 {
+				o2, a2, b2 = i, toOurs[i], toTheirs[i]
+				break
+			}
+		}
+		baseLines, ourLines, theirLines := base[o:o2], ours[a:a2], theirs[b:b2]
+		switch {
+		case slices.Equal(ourLines, baseLines):
+			out.WriteString(strings.Join(theirLines, ""))
+		case slices.Equal(theirLines, baseLines), slices.Equal(ourLines, theirLines):
+			out.WriteString(strings.Join(ourLines, ""))
+		default:
+			writeConflict(&out, ourLines, baseLines, theirLines, labels)
+			conflict = true
+		}
+		o, a, b = o2, a2, b2
+	}
+	return out.Bytes(), conflict
+}
+
+// matchIndex maps the lines of a to the matching line of b, or -1.
+func matchIndex(a, b []string) []int {
+	index := make([]int, len(a))
+	for i := range index {
+		index[i] = -1
+	}
+	for _, p := range match(a, b) {
+		index[p[0]] = p[1]
+	}
+	return index
+}
+
+func writeConflict(out *bytes.Buffer, ours, base, theirs []string, labels Labels) {
+	section := func(marker, label string, lines []string) {
+		out.WriteString(strings.TrimSpace(marker + " " + label))
+		out.WriteByte('\n')
+		for _, l := range lines {
+			out.WriteString(l)
+		}
+		if len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+			out.WriteByte('\n')
+		}
+	}
+	section("<<<<<<<", labels.Ours, ours)
+	if base != nil {
+		section("|||||||", labels.Base, base)
+	}
+	section("=======", "", theirs)
+	out.WriteString(strings.TrimSpace(">>>>>>> " + labels.Theirs))
+	out.WriteByte('\n')
+}
+
+// context is the number of unchanged lines around changes in Unified.
+const context = 3
+
+type op struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Unified returns the unified diff from a to b, or "" when they are equal.
+func Unified(aName, bName string, a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+	al, bl := Lines(a), Lines(b)
+	var ops []op
+	i, j := 0, 0
+	for _, p := range append(match(al, bl), [2]int{len(al), len(bl)}) {
+		for ; i < p[0]; i++ {
+			ops = append(ops, op{'-', al[i]})
+		}
+		for ; j < p[1]; j++ {
+			ops = append(ops, op{'+', bl[j]})
+		}
+		if i < len(al) {
+			ops = append(ops, op{' ', al[i]})
+		}
+		i, j = p[0]+1, p[1]+1
+	}
+
+	var out strings.Builder
+	out.WriteString("--- " + aName + "\n")
+	out.WriteString("+++ " + bName + "\n")
+	// line numbers in a and b of the op at start
+	aLine, bLine := 1, 1
+	for start := 0; start < len(ops); {
+		// find the next change and the end of its hunk
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		for _, o := range ops[start:max(start, first-context)] {
+			aLine, bLine = advance(o, aLine, bLine)
+		}
+		hunkStart := max(start, first-context)
+		end := first
+		for k := first; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				end = k + 1
+			} else if k-end >= 2*context {
+				break
+			}
+		}
+		hunkEnd := min(len(ops), end+context)
+
+		aCount, bCount := 0, 0
+		for _, o := range ops[hunkStart:hunkEnd] {
+			if o.kind != '+' {
+				aCount++
+			}
+			if o.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+		for _, o := range ops[hunkStart:hunkEnd] {
+			out.WriteByte(o.kind)
+			out.WriteString(o.line)
+			if !strings.HasSuffix(o.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+			aLine, bLine = advance(o, aLine, bLine)
+		}
+		start = hunkEnd
+	}
+	return out.String()
+}
+
+func advance(o op, aLine, bLine int) (int, int) {
+	if o.kind != '+' {
+		aLine++
+	}
+	if o.kind != '-' {
+		bLine++
+	}
+	return aLine, bLine
+}
+
+func hunkRange(line, count int) string {
+	if count == 0 {
+		line--
+	}
+	if count == 1 {
+		return strconv.Itoa(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
@@ -0,0 +1,200 @@
+package diff
+
+import (
+	"testing"
+)
+
+var labels = Labels{Ours: "ours", Base: "base", Theirs: "theirs"}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		noBase             bool
+		want               string
+		wantConflict       bool
+	}{
+		{
+			name:   "unchanged",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nc\n",
+			theirs: "a\nb\nc\n",
+			want:   "a\nb\nc\n",
+		},
+		{
+			name:   "only theirs changed",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nc\n",
+			theirs: "a\nB\nc\n",
+			want:   "a\nB\nc\n",
+		},
+		{
+			name:   "only ours changed",
+			base:   "a\nb\nc\n",
+			ours:   "a\nB\nc\n",
+			theirs: "a\nb\nc\n",
+			want:   "a\nB\nc\n",
+		},
+		{
+			name:   "different lines changed",
+			base:   "a\nb\nc\nd\ne\n",
+			ours:   "A\nb\nc\nd\ne\n",
+			theirs: "a\nb\nc\nd\nE\n",
+			want:   "A\nb\nc\nd\nE\n",
+		},
+		{
+			name:   "same change on both sides",
+			base:   "a\nb\nc\n",
+			ours:   "a\nB\nc\n",
+			theirs: "a\nB\nc\n",
+			want:   "a\nB\nc\n",
+		},
+		{
+			name:         "conflict",
+			base:         "a\nb\nc\n",
+			ours:         "a\nours\nc\n",
+			theirs:       "a\ntheirs\nc\n",
+			want:         "a\n<<<<<<< ours\nours\n||||||| base\nb\n=======\ntheirs\n>>>>>>> theirs\nc\n",
+			wantConflict: true,
+		},
+		{
+			name:   "insert at start",
+			base:   "a\nb\n",
+			ours:   "a\nb\nc\n",
+			theirs: "start\na\nb\n",
+			want:   "start\na\nb\nc\n",
+		},
+		{
+			name:   "insert at end",
+			base:   "a\nb\n",
+			ours:   "a\nb\n",
+			theirs: "a\nb\nend\n",
+			want:   "a\nb\nend\n",
+		},
+		{
+			name:         "insert at end on both sides",
+			base:         "a\n",
+			ours:         "a\nours\n",
+			theirs:       "a\ntheirs\n",
+			want:         "a\n<<<<<<< ours\nours\n||||||| base\n=======\ntheirs\n>>>>>>> theirs\n",
+			wantConflict: true,
+		},
+		{
+			name:   "deleted by theirs",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nc\n",
+			theirs: "a\nc\n",
+			want:   "a\nc\n",
+		},
+		{
+			name:   "missing trailing newline",
+			base:   "a\nb\nc",
+			ours:   "A\nb\nc",
+			theirs: "a\nb\nc\n",
+			want:   "A\nb\nc\n",
+		},
+		{
+			name:         "conflict without trailing newline",
+			base:         "a\nb",
+			ours:         "a\nours",
+			theirs:       "a\ntheirs",
+			want:         "a\n<<<<<<< ours\nours\n||||||| base\nb\n=======\ntheirs\n>>>>>>> theirs\n",
+			wantConflict: true,
+		},
+		{
+			name:         "no base",
+			noBase:       true,
+			ours:         "a\nours\nc\n",
+			theirs:       "a\ntheirs\nc\n",
+			want:         "a\n<<<<<<< ours\nours\n=======\ntheirs\n>>>>>>> theirs\nc\n",
+			wantConflict: true,
+		},
+		{
+			name:   "no base identical",
+			noBase: true,
+			ours:   "a\nb\n",
+			theirs: "a\nb\n",
+			want:   "a\nb\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var base []byte
+			if !tt.noBase {
+				base = []byte(tt.base)
+			}
+			got, conflict := Merge(base, []byte(tt.ours), []byte(tt.theirs), labels)
+			if string(got) != tt.want {
+				t.Errorf("Merge() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if conflict != tt.wantConflict {
+				t.Errorf("Merge() conflict = %v, want %v", conflict, tt.wantConflict)
+			}
+		})
+	}
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "a\nb\n",
+			b:    "a\nb\n",
+			want: "",
+		},
+		{
+			name: "change",
+			a:    "a\nb\nc\n",
+			b:    "a\nB\nc\n",
+			want: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name: "insert at start",
+			a:    "a\nb\n",
+			b:    "start\na\nb\n",
+			want: "--- a\n+++ b\n@@ -1,2 +1,3 @@\n+start\n a\n b\n",
+		},
+		{
+			name: "insert at end",
+			a:    "a\nb\n",
+			b:    "a\nb\nend\n",
+			want: "--- a\n+++ b\n@@ -1,2 +1,3 @@\n a\n b\n+end\n",
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "a\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name: "missing trailing newline",
+			a:    "a\nb",
+			b:    "a\nb\n",
+			want: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			want: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+		{
+			name: "close changes share a hunk",
+			a:    "1\n2\n3\n4\n5\n6\n",
+			b:    "one\n2\n3\n4\n5\nsix\n",
+			want: "--- a\n+++ b\n@@ -1,6 +1,6 @@\n-1\n+one\n 2\n 3\n 4\n 5\n-6\n+six\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("a", "b", []byte(tt.a), []byte(tt.b))
+			if got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
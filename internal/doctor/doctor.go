@@ -0,0 +1,513 @@
+package doctor
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/version"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/a-h/templ/generator"
+	templParser "github.com/a-h/templ/parser/v2"
+	"golang.org/x/mod/modfile"
+)
+
+type Status int
+
+const (
+	OK Status = iota
+	Warn
+	Fail
+)
+
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "ok"
+	case Warn:
+		return "warn"
+	default:
+		return "fail"
+	}
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name   string
+	Status Status
+	// Detail describes what was found e.g. the tool version.
+	Detail string
+	// Fix describes how to resolve a warning or failure.
+	Fix string
+}
+
+// Run runs a command and returns its trimmed output. It can be replaced to
+// check without the local toolchain.
+var Run = func(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// LookPath finds an executable on PATH. It can be replaced like Run.
+var LookPath = exec.LookPath
+
+type tool struct {
+	name string
+	// goTool is the package of the tool when it is provided by a go.mod
+	// tool directive, and module the module providing it.
+	goTool string
+	module string
+	// args prints the tool version.
+	args []string
+	// usedBy lists files, any of which means the project needs the tool.
+	usedBy []string
+	// missing is the status reported when the tool is needed but not found.
+	missing Status
+	fix     string
+}
+
+var tools = []tool{
+	{
+		name:    "templ",
+		goTool:  "github.com/a-h/templ/cmd/templ",
+		module:  "github.com/a-h/templ",
+		args:    []string{"version"},
+		usedBy:  []string{"go.mod"},
+		missing: Fail,
+		fix:     "go get -tool github.com/a-h/templ/cmd/templ",
+	},
+	{
+		name:    "sqlc",
+		goTool:  "github.com/sqlc-dev/sqlc/cmd/sqlc",
+		module:  "github.com/sqlc-dev/sqlc",
+		args:    []string{"version"},
+		usedBy:  []string{"sqlc.yaml"},
+		missing: Fail,
+		fix:     "go get -tool github.com/sqlc-dev/sqlc/cmd/sqlc",
+	},
+	{
+		name:    "air",
+		goTool:  "github.com/air-verse/air",
+		module:  "github.com/air-verse/air",
+		args:    []string{"-v"},
+		usedBy:  []string{".air.toml"},
+		missing: Warn,
+		fix:     "go get -tool github.com/air-verse/air",
+	},
+	{
+		name:    "bun",
+		args:    []string{"--version"},
+		usedBy:  []string{"bun.lock", "bun.lockb"},
+		missing: Fail,
+		fix:     "install bun from https://bun.sh/",
+	},
+	{
+		name:    "golangci-lint",
+		args:    []string{"--version"},
+		usedBy:  []string{".golangci.yml", ".golangci.yaml"},
+		missing: Warn,
+		fix:     "install golangci-lint from https://golangci-lint.run/welcome/install/",
+	},
+	{
+		name:    "docker",
+		args:    []string{"--version"},
+		usedBy:  []string{"docker/docker-compose.yml", "Dockerfile"},
+		missing: Warn,
+		fix:     "install docker from https://docs.docker.com/get-docker/",
+	},
+}
+
+// Toolchain checks the local go version against the go.mod in fsys and the
+// tools used by the project or template in fsys.
+func Toolchain(fsys fs.FS) []Result {
+	var mod *modfile.File
+	if b, err := fs.ReadFile(fsys, "go.mod"); err == nil {
+		// ParseLax ignores tool directives, it is only used to read the go
+		// version of go.mod files the strict parser rejects
+		if mod, err = modfile.Parse("go.mod", b, nil); err != nil {
+			mod, _ = modfile.ParseLax("go.mod", b, nil)
+		}
+	}
+
+	results := []Result{checkGo(mod)}
+	for _, t := range tools {
+		if !usesAny(fsys, t.usedBy) {
+			continue
+		}
+		results = append(results, checkTool(t, mod))
+	}
+	return results
+}
+
+func checkGo(mod *modfile.File) Result {
+	r := Result{Name: "go"}
+	local, err := Run("go", "env", "GOVERSION")
+	if err != nil || local == "" {
+		r.Status = Fail
+		r.Detail = "go not found"
+		r.Fix = "install go from https://go.dev/dl/"
+		return r
+	}
+	r.Detail = local
+	if mod == nil || mod.Go == nil {
+		return r
+	}
+	required := "go" + mod.Go.Version
+	if version.Compare(local, required) < 0 {
+		r.Status = Fail
+		r.Detail = fmt.Sprintf("%s, go.mod requires %s", local, required)
+		r.Fix = fmt.Sprintf("install %s or newer from https://go.dev/dl/, or set GOTOOLCHAIN=auto", required)
+	}
+	return r
+}
+
+func checkTool(t tool, mod *modfile.File) Result {
+	r := Result{Name: t.name}
+	if mod != nil && t.goTool != "" {
+		for _, tl := range mod.Tool {
+			if tl.Path != t.goTool {
+				continue
+			}
+			r.Detail = "go tool"
+			for _, req := range mod.Require {
+				if req.Mod.Path == t.module {
+					r.Detail += " " + req.Mod.Version
+				}
+			}
+			return r
+		}
+	}
+
+	if _, err := LookPath(t.name); err != nil {
+		r.Status = t.missing
+		r.Detail = "not found"
+		r.Fix = t.fix
+		return r
+	}
+	out, err := Run(t.name, t.args...)
+	if err != nil {
+		r.Status = Warn
+		r.Detail = fmt.Sprintf("%s %s failed: %s", t.name, strings.Join(t.args, " "), err)
+		return r
+	}
+	r.Detail, _, _ = strings.Cut(out, "\n")
+	return r
+}
+
+func usesAny(fsys fs.FS, names []string) bool {
+	for _, name := range names {
+		if _, err := fs.Stat(fsys, name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// skipDirs are not searched for generated files.
+var skipDirs = map[string]bool{
+	".git":         true,
+	".gofs":        true,
+	"node_modules": true,
+	"tmp":          true,
+	"bin":          true,
+	"vendor":       true,
+}
+
+// Project checks that generated code is up to date and that .env matches
+// .env.example in the project at root.
+func Project(root string) []Result {
+	return []Result{
+		checkTempl(root),
+		checkSqlc(root),
+		checkEnv(root),
+	}
+}
+
+// checkTempl regenerates the Go code of every templ file and compares it with
+// its _templ.go file. Code generated by another templ version than the one
+// gofs is built with cannot be compared; it is only reported as possibly
+// stale when older than the templ file, as modification times are not
+// reliable, e.g. after a git checkout.
+func checkTempl(root string) Result {
+	r := Result{Name: "templ generate"}
+	var stale, missing, older []string
+	count := 0
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if skipDirs[d.Name()] && p != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, ".templ") {
+			return nil
+		}
+		count++
+		rel, _ := filepath.Rel(root, p)
+		gen := strings.TrimSuffix(p, ".templ") + "_templ.go"
+		code, err := os.ReadFile(gen)
+		if errors.Is(err, fs.ErrNotExist) {
+			missing = append(missing, rel)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		same, ok, err := templGenerated(root, p, code)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if !same {
+				stale = append(stale, rel)
+			}
+			return nil
+		}
+		src, err := d.Info()
+		if err != nil {
+			return err
+		}
+		out, err := os.Stat(gen)
+		if err != nil {
+			return err
+		}
+		if out.ModTime().Before(src.ModTime()) {
+			older = append(older, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		r.Status = Fail
+		r.Detail = err.Error()
+		return r
+	}
+
+	switch {
+	case count == 0:
+		r.Detail = "no templ files"
+	case len(missing)+len(stale) > 0:
+		r.Status = Fail
+		r.Detail = describe("not generated", missing, "changed since generated", stale)
+		r.Fix = "go tool templ generate"
+	case len(older) > 0:
+		r.Status = Warn
+		r.Detail = describe("", nil, "generated by another templ version and older than the templ file", older)
+		r.Fix = "go tool templ generate"
+	default:
+		r.Detail = fmt.Sprintf("%d templ files up to date", count)
+	}
+	return r
+}
+
+// templGenerated reports whether code is what templ generates for the templ
+// file name in the project at root. ok is false when code was not generated
+// by the templ version gofs is built with, so it cannot be compared.
+func templGenerated(root, name string, code []byte) (same, ok bool, err error) {
+	version := templ.Version()
+	if !bytes.Contains(code, []byte("\n// templ: version: "+version+"\n")) {
+		return false, false, nil
+	}
+	t, err := templParser.Parse(name)
+	if err != nil {
+		return false, false, err
+	}
+	rel, err := filepath.Rel(root, name)
+	if err != nil {
+		return false, false, err
+	}
+	var buf bytes.Buffer
+	_, err = generator.Generate(t, &buf, generator.WithVersion(version), generator.WithFileName(filepath.ToSlash(rel)))
+	if err != nil {
+		return false, false, fmt.Errorf("%s: %w", rel, err)
+	}
+	want, err := format.Source(buf.Bytes())
+	if err != nil {
+		return false, false, fmt.Errorf("%s: %w", rel, err)
+	}
+	return bytes.Equal(code, want), true, nil
+}
+
+var sqlcPathRe = regexp.MustCompile(`^\s*-?\s*(schema|queries|out)\s*:\s*["']?([^"'#]+?)["']?\s*(#.*)?$`)
+
+func checkSqlc(root string) Result {
+	r := Result{Name: "sqlc generate"}
+	f, err := os.Open(filepath.Join(root, "sqlc.yaml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		r.Detail = "no sqlc.yaml"
+		return r
+	}
+	if err != nil {
+		r.Status = Fail
+		r.Detail = err.Error()
+		return r
+	}
+	defer f.Close()
+
+	var inputs, outputs []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		m := sqlcPathRe.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+		p := filepath.Join(root, filepath.FromSlash(m[2]))
+		if m[1] == "out" {
+			outputs = append(outputs, p)
+		} else {
+			inputs = append(inputs, p)
+		}
+	}
+
+	newestInput, _ := modTime(inputs, true)
+	oldestOutput, ok := modTime(outputs, false)
+	switch {
+	case !ok:
+		r.Status = Fail
+		r.Detail = "generated code not found"
+		r.Fix = "go tool sqlc generate"
+	case oldestOutput.Before(newestInput):
+		// modification times are a hint only, e.g. a checkout sets them all
+		r.Status = Warn
+		r.Detail = "schema or queries may have changed since generated, they are newer than the generated code"
+		r.Fix = "go tool sqlc generate"
+	default:
+		r.Detail = "generated code up to date"
+	}
+	return r
+}
+
+// modTime returns the newest or oldest modification time of the files in
+// paths, directories are searched one level deep.
+func modTime(paths []string, newest bool) (time.Time, bool) {
+	var t time.Time
+	found := false
+	visit := func(fi fs.FileInfo) {
+		if !found || newest == fi.ModTime().After(t) {
+			t = fi.ModTime()
+			found = true
+		}
+	}
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		if !fi.IsDir() {
+			visit(fi)
+			continue
+		}
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || path.Ext(e.Name()) == "" {
+				continue
+			}
+			if info, err := e.Info(); err == nil {
+				visit(info)
+			}
+		}
+	}
+	return t, found
+}
+
+func checkEnv(root string) Result {
+	r := Result{Name: ".env"}
+	example, err := envKeys(filepath.Join(root, ".env.example"))
+	if errors.Is(err, fs.ErrNotExist) {
+		r.Detail = "no .env.example"
+		return r
+	}
+	if err != nil {
+		r.Status = Fail
+		r.Detail = err.Error()
+		return r
+	}
+	env, err := envKeys(filepath.Join(root, ".env"))
+	if errors.Is(err, fs.ErrNotExist) {
+		r.Status = Warn
+		r.Detail = ".env not found"
+		r.Fix = "cp .env.example .env"
+		return r
+	}
+	if err != nil {
+		r.Status = Fail
+		r.Detail = err.Error()
+		return r
+	}
+
+	var missing, extra []string
+	for _, k := range example {
+		if !slices.Contains(env, k) {
+			missing = append(missing, k)
+		}
+	}
+	for _, k := range env {
+		if !slices.Contains(example, k) {
+			extra = append(extra, k)
+		}
+	}
+	switch {
+	case len(missing) > 0:
+		r.Status = Fail
+		r.Detail = describe("missing", missing, "not in .env.example", extra)
+		r.Fix = "add the missing variables to .env, see .env.example"
+	case len(extra) > 0:
+		r.Status = Warn
+		r.Detail = describe("", nil, "not in .env.example", extra)
+		r.Fix = "document the variables in .env.example"
+	default:
+		r.Detail = "matches .env.example"
+	}
+	return r
+}
+
+// envKeys returns the variable names set in an env file. Commented out
+// variables are optional and ignored.
+func envKeys(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var keys []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		if k, _, ok := strings.Cut(line, "="); ok {
+			keys = append(keys, strings.TrimSpace(k))
+		}
+	}
+	return keys, sc.Err()
+}
+
+func describe(label string, a []string, label2 string, b []string) string {
+	var parts []string
+	if len(a) > 0 {
+		parts = append(parts, label+": "+strings.Join(a, ", "))
+	}
+	if len(b) > 0 {
+		parts = append(parts, label2+": "+strings.Join(b, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,237 @@
+package doctor
+
+import (
+	"bytes"
+	"errors"
+	"go/format"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/a-h/templ/generator"
+	templParser "github.com/a-h/templ/parser/v2"
+)
+
+const page = `package ui
+
+templ Page(msg string) {
+	<p>{ msg }</p>
+}
+`
+
+// writeTempl writes the templ file and the code templ generates for it to
+// the project at root.
+func writeTempl(t *testing.T, root, name, src string) {
+	t.Helper()
+	tf, err := templParser.ParseString(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := generator.Generate(tf, &buf, generator.WithVersion(templ.Version()), generator.WithFileName(name)); err != nil {
+		t.Fatal(err)
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, root, name, src)
+	writeFile(t, root, strings.TrimSuffix(name, ".templ")+"_templ.go", string(code))
+}
+
+func writeFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func touch(t *testing.T, root, name string, mtime time.Time) {
+	t.Helper()
+	if err := os.Chtimes(filepath.Join(root, filepath.FromSlash(name)), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckTempl(t *testing.T) {
+	old := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name   string
+		setup  func(t *testing.T, root string)
+		status Status
+		detail string
+	}{
+		{
+			name:   "no templ files",
+			setup:  func(t *testing.T, root string) {},
+			detail: "no templ files",
+		},
+		{
+			name:   "up to date",
+			setup:  func(t *testing.T, root string) { writeTempl(t, root, "ui/page.templ", page) },
+			detail: "1 templ files up to date",
+		},
+		{
+			name: "up to date but older",
+			setup: func(t *testing.T, root string) {
+				writeTempl(t, root, "ui/page.templ", page)
+				touch(t, root, "ui/page_templ.go", old)
+			},
+			detail: "1 templ files up to date",
+		},
+		{
+			name: "changed",
+			setup: func(t *testing.T, root string) {
+				writeTempl(t, root, "ui/page.templ", page)
+				writeFile(t, root, "ui/page.templ", strings.Replace(page, "<p>", "<p class=\"x\">", 1))
+				touch(t, root, "ui/page.templ", old)
+			},
+			status: Fail,
+			detail: "changed since generated: " + filepath.Join("ui", "page.templ"),
+		},
+		{
+			name:   "not generated",
+			setup:  func(t *testing.T, root string) { writeFile(t, root, "ui/page.templ", page) },
+			status: Fail,
+			detail: "not generated: " + filepath.Join("ui", "page.templ"),
+		},
+		{
+			name: "other templ version",
+			setup: func(t *testing.T, root string) {
+				writeFile(t, root, "ui/page.templ", page)
+				writeFile(t, root, "ui/page_templ.go", "// Code generated by templ - DO NOT EDIT.\n\n// templ: version: v0.0.1\npackage ui\n")
+			},
+			detail: "1 templ files up to date",
+		},
+		{
+			name: "other templ version and older",
+			setup: func(t *testing.T, root string) {
+				writeFile(t, root, "ui/page.templ", page)
+				writeFile(t, root, "ui/page_templ.go", "// Code generated by templ - DO NOT EDIT.\n\n// templ: version: v0.0.1\npackage ui\n")
+				touch(t, root, "ui/page_templ.go", old)
+			},
+			status: Warn,
+			detail: "older than the templ file: " + filepath.Join("ui", "page.templ"),
+		},
+		{
+			name: "skipped directories",
+			setup: func(t *testing.T, root string) {
+				writeFile(t, root, "node_modules/x/page.templ", page)
+			},
+			detail: "no templ files",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			tt.setup(t, root)
+			r := checkTempl(root)
+			if r.Status != tt.status || !strings.Contains(r.Detail, tt.detail) {
+				t.Errorf("got %s %q, want %s %q", r.Status, r.Detail, tt.status, tt.detail)
+			}
+		})
+	}
+}
+
+func TestCheckSqlc(t *testing.T) {
+	config := "version: \"2\"\nsql:\n  - schema: db/schema.sql\n    queries: db/queries\n    gen:\n      go:\n        out: internal/db\n"
+	old := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name   string
+		setup  func(t *testing.T, root string)
+		status Status
+	}{
+		{
+			name: "up to date",
+			setup: func(t *testing.T, root string) {
+				touch(t, root, "db/schema.sql", old)
+				touch(t, root, "db/queries/users.sql", old)
+			},
+		},
+		{
+			name: "inputs newer",
+			setup: func(t *testing.T, root string) {
+				touch(t, root, "internal/db/users.sql.go", old)
+			},
+			status: Warn,
+		},
+		{
+			name: "not generated",
+			setup: func(t *testing.T, root string) {
+				if err := os.RemoveAll(filepath.Join(root, "internal")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			status: Fail,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFile(t, root, "sqlc.yaml", config)
+			writeFile(t, root, "db/schema.sql", "create table users (id int);\n")
+			writeFile(t, root, "db/queries/users.sql", "-- name: GetUser :one\nselect * from users;\n")
+			writeFile(t, root, "internal/db/users.sql.go", "package db\n")
+			tt.setup(t, root)
+			if r := checkSqlc(root); r.Status != tt.status {
+				t.Errorf("got %s %q, want %s", r.Status, r.Detail, tt.status)
+			}
+		})
+	}
+}
+
+func TestToolchain(t *testing.T) {
+	run, lookPath := Run, LookPath
+	t.Cleanup(func() { Run, LookPath = run, lookPath })
+	Run = func(name string, args ...string) (string, error) {
+		if name == "go" {
+			return "go1.25.0", nil
+		}
+		return "", errors.New("not stubbed")
+	}
+	LookPath = func(name string) (string, error) { return "", exec.ErrNotFound }
+
+	goMod := `module example.com/app
+
+go 1.25
+
+tool (
+	github.com/a-h/templ/cmd/templ
+	github.com/sqlc-dev/sqlc/cmd/sqlc
+)
+
+require (
+	github.com/a-h/templ v0.3.960
+	github.com/sqlc-dev/sqlc v1.29.0 // indirect
+)
+`
+	fsys := fstest.MapFS{
+		"go.mod":    {Data: []byte(goMod)},
+		"sqlc.yaml": {Data: []byte("version: \"2\"\n")},
+		".air.toml": {Data: []byte("")},
+	}
+	want := map[string]Result{
+		"go":    {Name: "go", Detail: "go1.25.0"},
+		"templ": {Name: "templ", Detail: "go tool v0.3.960"},
+		"sqlc":  {Name: "sqlc", Detail: "go tool v1.29.0"},
+		"air":   {Name: "air", Status: Warn, Detail: "not found", Fix: "go get -tool github.com/air-verse/air"},
+	}
+	results := Toolchain(fsys)
+	if len(results) != len(want) {
+		t.Errorf("got %d results, want %d: %v", len(results), len(want), results)
+	}
+	for _, r := range results {
+		if r != want[r.Name] {
+			t.Errorf("got %+v, want %+v", r, want[r.Name])
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Package editor configures editors for a gofs project: gopls with the module
+// as local import prefix, the templ and tailwind language servers, the
+// recommended extensions or plugins, and an .editorconfig shared by all
+// editors.
+package editor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Supported editors.
+const (
+	VSCode    = "vscode"
+	Neovim    = "neovim"
+	JetBrains = "jetbrains"
+)
+
+// Names returns the supported editors.
+func Names() []string {
+	return []string{JetBrains, Neovim, VSCode}
+}
+
+// Status of a configuration file compared to the file in the project.
+const (
+	StatusCreate    = "create"
+	StatusUpdate    = "update"
+	StatusUnchanged = "unchanged"
+	// StatusKept files exist in a format that is not merged, e.g. Lua, and
+	// are left as they are.
+	StatusKept = "kept"
+)
+
+// File is a configuration file of an editor.
+type File struct {
+	// Path is the slash separated path relative to the project.
+	Path    string
+	Content []byte
+	Status  string
+}
+
+// config is a configuration file. Files in formats that are merged, e.g.
+// JSON, get the missing settings added. Other files are only created.
+type config struct {
+	path string
+	// merge returns the file for the module with the existing file, nil
+	// when missing, merged in.
+	merge func(existing []byte, module string) ([]byte, error)
+	// create returns the file for the module when it is not merged.
+	create func(module string) []byte
+}
+
+var configs = map[string][]config{
+	VSCode: {
+		{path: ".vscode/settings.json", merge: vscodeSettings},
+		{path: ".vscode/extensions.json", merge: vscodeExtensions},
+	},
+	Neovim:    {{path: ".nvim.lua", create: neovimConfig}},
+	JetBrains: {{path: ".idea/externalDependencies.xml", create: jetbrainsPlugins}},
+}
+
+// editorConfig is written for every editor.
+var editorConfig = config{
+	path:   ".editorconfig",
+	create: func(string) []byte { return []byte(editorConfigFile) },
+}
+
+// Check returns an error for names that are not supported editors.
+func Check(names []string) error {
+	for _, name := range names {
+		if !slices.Contains(Names(), name) {
+			return fmt.Errorf("unknown editor %q, expected one of %s", name, strings.Join(Names(), ", "))
+		}
+	}
+	return nil
+}
+
+// Paths returns the slash separated paths of the configuration files of the
+// editors, relative to the project.
+func Paths(editors []string) []string {
+	paths := []string{editorConfig.path}
+	for _, name := range Names() {
+		if slices.Contains(editors, name) {
+			for _, c := range configs[name] {
+				paths = append(paths, c.path)
+			}
+		}
+	}
+	return paths
+}
+
+// Files returns the configuration files of the editors for the module,
+// merged with the files of the project in dir.
+func Files(dir fs.FS, module string, editors []string) ([]File, error) {
+	if err := Check(editors); err != nil {
+		return nil, err
+	}
+	list := []config{editorConfig}
+	for _, name := range Names() {
+		if slices.Contains(editors, name) {
+			list = append(list, configs[name]...)
+		}
+	}
+
+	var files []File
+	for _, c := range list {
+		existing, err := fs.ReadFile(dir, c.path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		f := File{Path: c.path}
+		switch {
+		case c.merge != nil:
+			f.Content, err = c.merge(existing, module)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", c.path, err)
+			}
+			f.Status = StatusUpdate
+			if existing == nil {
+				f.Status = StatusCreate
+			} else if bytes.Equal(f.Content, existing) {
+				f.Status = StatusUnchanged
+			}
+		case existing == nil:
+			f.Content = c.create(module)
+			f.Status = StatusCreate
+		default:
+			f.Content = existing
+			f.Status = StatusKept
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// Write writes the created and updated files to the project in dir.
+func Write(dir string, files []File) error {
+	for _, f := range files {
+		if f.Status != StatusCreate && f.Status != StatusUpdate {
+			continue
+		}
+		name := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, f.Content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const editorConfigFile = `root = true
+
+[*]
+charset = utf-8
+end_of_line = lf
+insert_final_newline = true
+trim_trailing_whitespace = true
+
+[*.{go,templ}]
+indent_style = tab
+
+[{Makefile,*.mk}]
+indent_style = tab
+
+[*.{css,js,json,ts,toml,yaml,yml}]
+indent_style = space
+indent_size = 2
+
+[*.md]
+trim_trailing_whitespace = false
+`
@@ -0,0 +1,76 @@
+package editor
+
+import (
+	"encoding/xml"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPaths(t *testing.T) {
+	got := Paths([]string{VSCode, JetBrains})
+	want := []string{".editorconfig", ".idea/externalDependencies.xml", ".vscode/settings.json", ".vscode/extensions.json"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Paths() = %q, want %q", got, want)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := fstest.MapFS{
+		".nvim.lua":               {Data: []byte("-- mine\n")},
+		".vscode/extensions.json": {Data: []byte("{\n  \"recommendations\": [\n    \"golang.go\",\n    \"a-h.templ\",\n    \"bradlc.vscode-tailwindcss\"\n  ]\n}\n")},
+		".vscode/settings.json":   {Data: []byte(`{"editor.formatOnSave": true}`)},
+	}
+	files, err := Files(dir, "example.com/app", Names())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, f := range files {
+		got[f.Path] = f.Status
+	}
+	want := map[string]string{
+		".editorconfig":                  StatusCreate,
+		".idea/externalDependencies.xml": StatusCreate,
+		".nvim.lua":                      StatusKept,
+		".vscode/settings.json":          StatusUpdate,
+		".vscode/extensions.json":        StatusUnchanged,
+	}
+	for path, status := range want {
+		if got[path] != status {
+			t.Errorf("%s: status %q, want %q", path, got[path], status)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got files %v, want %v", got, want)
+	}
+
+	if _, err := Files(dir, "example.com/app", []string{"emacs"}); err == nil {
+		t.Error("unknown editor without error")
+	}
+}
+
+func TestJetbrainsPlugins(t *testing.T) {
+	var project struct {
+		Component struct {
+			Name    string `xml:"name,attr"`
+			Plugins []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"plugin"`
+		} `xml:"component"`
+	}
+	if err := xml.Unmarshal(jetbrainsPlugins("example.com/app"), &project); err != nil {
+		t.Fatal(err)
+	}
+	if project.Component.Name != "ExternalDependencies" {
+		t.Errorf("component %q, want ExternalDependencies", project.Component.Name)
+	}
+	var ids []string
+	for _, p := range project.Component.Plugins {
+		ids = append(ids, p.ID)
+	}
+	want := []string{"org.jetbrains.plugins.go", "com.templ.templ", "com.intellij.tailwindcss"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("plugins %q, want %q", ids, want)
+	}
+}
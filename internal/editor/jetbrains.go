@@ -0,0 +1,34 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// jetbrainsPluginIDs are the JetBrains Marketplace ids of the Go, templ and
+// Tailwind CSS plugins, as declared by the <id> of their plugin.xml.
+var jetbrainsPluginIDs = []string{
+	"org.jetbrains.plugins.go",
+	"com.templ.templ",
+	"com.intellij.tailwindcss",
+}
+
+// jetbrainsPlugins returns .idea/externalDependencies.xml, the plugins a
+// JetBrains IDE asks to install when the project is opened. A JetBrains IDE
+// has no project file configuring language servers: the templ and tailwind
+// plugins run theirs with the plugin settings, and GoLand bundles its own Go
+// support instead of gopls, so the module is not set as local import prefix.
+func jetbrainsPlugins(string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<project version="4">
+  <component name="ExternalDependencies">
+`)
+	for _, id := range jetbrainsPluginIDs {
+		fmt.Fprintf(&buf, "    <plugin id=%q />\n", id)
+	}
+	buf.WriteString(`  </component>
+</project>
+`)
+	return buf.Bytes()
+}
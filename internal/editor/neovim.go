@@ -0,0 +1,33 @@
+package editor
+
+import "fmt"
+
+// neovimConfig returns .nvim.lua, the project configuration Neovim loads with
+// the exrc option. It configures gopls, templ and tailwind with the
+// vim.lsp.config API of Neovim 0.11, on top of the server defaults of
+// nvim-lspconfig.
+func neovimConfig(module string) []byte {
+	return fmt.Appendf(nil, `-- Project configuration, loaded by Neovim with 'exrc' set, see :help exrc.
+vim.filetype.add({ extension = { templ = "templ" } })
+
+vim.lsp.config("gopls", {
+  settings = {
+    gopls = {
+      ["local"] = %q,
+      gofumpt = true,
+    },
+  },
+})
+
+vim.lsp.config("tailwindcss", {
+  filetypes = { "css", "html", "javascript", "typescript", "templ" },
+  settings = {
+    tailwindCSS = {
+      includeLanguages = { templ = "html" },
+    },
+  },
+})
+
+vim.lsp.enable({ "gopls", "templ", "tailwindcss" })
+`, module)
+}
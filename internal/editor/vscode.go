@@ -0,0 +1,54 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/gofs-cli/gofs/internal/vscode"
+)
+
+// vscodeSettings adds the gopls, templ and tailwind settings missing from
+// .vscode/settings.json. Comments of existing settings are not kept.
+func vscodeSettings(existing []byte, module string) ([]byte, error) {
+	set := vscode.Settings{}
+	if existing != nil {
+		if err := vscode.Unmarshal(existing, &set); err != nil {
+			return nil, fmt.Errorf("cannot merge invalid settings: %w", err)
+		}
+	}
+	changed := set.Merge(vscode.Settings{
+		"gopls": map[string]any{
+			"formatting.gofumpt": true,
+		},
+		"files.associations": map[string]any{
+			"*.css": "tailwindcss",
+		},
+		"[templ]": map[string]any{
+			"editor.defaultFormatter": "a-h.templ",
+		},
+		"tailwindCSS.includeLanguages": map[string]any{
+			"templ": "html",
+		},
+		"emmet.includeLanguages": map[string]any{
+			"templ": "html",
+		},
+	})
+	if !set.AddGoplsLocal(module) && !changed && existing != nil {
+		return existing, nil
+	}
+	return vscode.Marshal(set)
+}
+
+// vscodeExtensions adds the Go, templ and tailwind extensions to the
+// recommendations of .vscode/extensions.json.
+func vscodeExtensions(existing []byte, _ string) ([]byte, error) {
+	var ext vscode.Extensions
+	if existing != nil {
+		if err := vscode.Unmarshal(existing, &ext); err != nil {
+			return nil, fmt.Errorf("cannot merge invalid recommendations: %w", err)
+		}
+	}
+	if !ext.Recommend("golang.go", "a-h.templ", "bradlc.vscode-tailwindcss") && existing != nil {
+		return existing, nil
+	}
+	return vscode.Marshal(ext)
+}
@@ -0,0 +1,65 @@
+package editor
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestVscodeSettingsJSONC(t *testing.T) {
+	existing := []byte(`{
+  // keep my formatter
+  "editor.formatOnSave": true,
+  "gopls": {
+    "formatting.local": "example.com/lib", /* shared packages */
+  },
+}
+`)
+	b, err := vscodeSettings(existing, "example.com/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("merged settings are not JSON: %v\n%s", err, b)
+	}
+	if got["editor.formatOnSave"] != true {
+		t.Errorf("existing setting not kept:\n%s", b)
+	}
+	gopls, _ := got["gopls"].(map[string]any)
+	if local := gopls["formatting.local"]; local != "example.com/lib,example.com/app" {
+		t.Errorf("formatting.local = %v, want example.com/lib,example.com/app", local)
+	}
+}
+
+func TestVscodeExtensionsJSONC(t *testing.T) {
+	existing := []byte(`{
+  "recommendations": [
+    "golang.go", // Go
+  ],
+  "unwantedRecommendations": ["bradlc.vscode-tailwindcss"],
+}
+`)
+	b, err := vscodeExtensions(existing, "example.com/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "recommendations": [
+    "golang.go",
+    "a-h.templ"
+  ],
+  "unwantedRecommendations": [
+    "bradlc.vscode-tailwindcss"
+  ]
+}
+`
+	if string(b) != want {
+		t.Errorf("got\n%s\nwant\n%s", b, want)
+	}
+}
+
+func TestVscodeSettingsInvalid(t *testing.T) {
+	if _, err := vscodeSettings([]byte(`{"gopls": `), "example.com/app"); err == nil {
+		t.Error("invalid settings merged without error")
+	}
+}
@@ -0,0 +1,199 @@
+package gen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ConfigFile is the template configuration file at the template root. It is
+// read by gofs and not generated.
+const ConfigFile = "gofs.json"
+
+// Config is the configuration a template declares in ConfigFile.
+type Config struct {
+	Variables []Variable `json:"variables,omitempty"`
+	Features  []Feature  `json:"features,omitempty"`
+	// Executable are the files generated with execute permission, matched
+	// like Variable.Files. Files with execute permission in the template and
+	// scripts starting with #! are executable too.
+	Executable []string `json:"executable,omitempty"`
+	// Hooks are run in order in the project after it is generated.
+	Hooks []Hook `json:"hooks,omitempty"`
+}
+
+// Variable is a value chosen at generation time. Templates stay runnable
+// apps, so instead of placeholders a variable replaces literal text that the
+// template uses, e.g. the "App name" shown in the header.
+type Variable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Default is used when no value is given. It may refer to the builtin
+	// variables as ${module}, ${name} and ${org}. Variables without a
+	// default are required.
+	Default string `json:"default,omitempty"`
+	// Replaces is the literal text in the template replaced by the value.
+	Replaces string `json:"replaces"`
+	// Files restricts the replacement to the files matching the slash
+	// separated patterns, see path.Match. A trailing /** matches everything
+	// in a directory. All files are matched when empty.
+	Files []string `json:"files,omitempty"`
+	// Pattern is a regular expression the whole value has to match. Values
+	// are substituted as they are, so a variable replacing text in a Go
+	// string or an HTML attribute should exclude quotes, e.g. [^"\\<>]+.
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// Hook is a command run in the generated project, e.g. go mod tidy or git
+// init. Hooks run after the files are written, with the project as working
+// directory.
+type Hook struct {
+	Name string `json:"name"`
+	// Run is the command and its arguments, run without a shell.
+	Run []string `json:"run"`
+	// Network hooks download dependencies and are skipped when offline.
+	Network bool `json:"network,omitempty"`
+	// Unless skips the hook when the file exists in the project before the
+	// first hook runs, e.g. .git to leave an existing repository alone.
+	Unless string `json:"unless,omitempty"`
+	// Optional hooks may fail without stopping the hooks after them, e.g. a
+	// commit failing because git has no user name or email configured.
+	Optional bool `json:"optional,omitempty"`
+}
+
+// Builtin variables available to variable defaults.
+const (
+	VarModule = "module"
+	VarName   = "name"
+	// VarOrg is the owner of the module, the element after the host of
+	// github.com/org/app, otherwise the first element.
+	VarOrg = "org"
+)
+
+// LoadConfig reads ConfigFile from the template. A template without one has
+// an empty configuration.
+func LoadConfig(template fs.FS) (*Config, error) {
+	b, err := fs.ReadFile(template, ConfigFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", ConfigFile, err)
+	}
+	for _, v := range c.Variables {
+		if v.Name == "" || v.Replaces == "" {
+			return nil, fmt.Errorf("%s: variables need a name and the text they replace", ConfigFile)
+		}
+		if _, err := v.pattern(); err != nil {
+			return nil, fmt.Errorf("%s: variable %s: invalid pattern: %w", ConfigFile, v.Name, err)
+		}
+	}
+	for _, f := range c.Features {
+		if f.Name == "" || strings.Contains(f.Name, ",") {
+			return nil, fmt.Errorf("%s: features need a name without commas", ConfigFile)
+		}
+	}
+	for _, h := range c.Hooks {
+		if h.Name == "" || len(h.Run) == 0 || h.Run[0] == "" {
+			return nil, fmt.Errorf("%s: hooks need a name and a command to run", ConfigFile)
+		}
+	}
+	return &c, nil
+}
+
+// Variable returns the declared variable with the name.
+func (c *Config) Variable(name string) (Variable, bool) {
+	for _, v := range c.Variables {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variable{}, false
+}
+
+// BuiltinVars returns the builtin variables for the module path.
+func BuiltinVars(module string) map[string]string {
+	org, rest, _ := strings.Cut(module, "/")
+	if strings.Contains(rest, "/") {
+		org, _, _ = strings.Cut(rest, "/")
+	}
+	return map[string]string{
+		VarModule: module,
+		VarName:   path.Base(module),
+		VarOrg:    org,
+	}
+}
+
+// DefaultValue returns the default of the variable with the builtin variables
+// expanded.
+func (v Variable) DefaultValue(builtin map[string]string) string {
+	return os.Expand(v.Default, func(name string) string {
+		return builtin[name]
+	})
+}
+
+// Check returns an error when the value does not match the pattern of the
+// variable.
+func (v Variable) Check(value string) error {
+	re, err := v.pattern()
+	if err != nil {
+		return err
+	}
+	if re != nil && !re.MatchString(value) {
+		return fmt.Errorf("invalid value %q for %s, expected a match of %s", value, v.Name, v.Pattern)
+	}
+	return nil
+}
+
+// pattern compiles Pattern to match the whole value, nil without a pattern.
+func (v Variable) pattern() (*regexp.Regexp, error) {
+	if v.Pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + v.Pattern + ")$")
+}
+
+// Resolve returns the value of every declared variable, taken from values or
+// the variable default. It fails on values for undeclared variables, on
+// required variables without a value and on values not matching the
+// variable pattern.
+func (c *Config) Resolve(values, builtin map[string]string) (map[string]string, error) {
+	for name := range values {
+		if _, ok := c.Variable(name); !ok {
+			return nil, fmt.Errorf("unknown variable %q", name)
+		}
+	}
+	resolved := map[string]string{}
+	var missing []string
+	for _, v := range c.Variables {
+		value, ok := values[v.Name]
+		if !ok {
+			value = v.DefaultValue(builtin)
+		}
+		if value == "" {
+			missing = append(missing, v.Name)
+			continue
+		}
+		if err := v.Check(value); err != nil {
+			return nil, err
+		}
+		resolved[v.Name] = value
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required variables: %s", strings.Join(missing, ", "))
+	}
+	return resolved, nil
+}
+
+func (v Variable) matches(p string) bool {
+	return len(v.Files) == 0 || matchAny(v.Files, p)
+}
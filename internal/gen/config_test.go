@@ -0,0 +1,106 @@
+package gen
+
+import (
+	"maps"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestBuiltinVars(t *testing.T) {
+	tests := []struct {
+		module string
+		name   string
+		org    string
+	}{
+		{module: "github.com/acme/app", name: "app", org: "acme"},
+		{module: "github.com/acme/app/v2", name: "v2", org: "acme"},
+		{module: "example.com/app", name: "app", org: "example.com"},
+		{module: "app", name: "app", org: "app"},
+	}
+	for _, tt := range tests {
+		got := BuiltinVars(tt.module)
+		want := map[string]string{VarModule: tt.module, VarName: tt.name, VarOrg: tt.org}
+		if !maps.Equal(got, want) {
+			t.Errorf("BuiltinVars(%q) = %v, want %v", tt.module, got, want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	config := &Config{Variables: []Variable{
+		{Name: "app_name", Default: "${name}", Replaces: "App name", Pattern: `[^"\\<>{}]+`},
+		{Name: "port", Default: "8080", Replaces: "8080", Pattern: `[0-9]{1,5}`},
+		{Name: "org", Default: "${org}", Replaces: "gofs-org"},
+		{Name: "owner", Replaces: "owner"},
+	}}
+	builtin := BuiltinVars("github.com/acme/app")
+	tests := []struct {
+		name    string
+		values  map[string]string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "defaults",
+			values: map[string]string{"owner": "me"},
+			want:   map[string]string{"app_name": "app", "port": "8080", "org": "acme", "owner": "me"},
+		},
+		{
+			name:   "values",
+			values: map[string]string{"app_name": "My App", "port": "3000", "org": "Acme Inc", "owner": "me"},
+			want:   map[string]string{"app_name": "My App", "port": "3000", "org": "Acme Inc", "owner": "me"},
+		},
+		{
+			name:    "missing",
+			values:  map[string]string{},
+			wantErr: "missing required variables: owner",
+		},
+		{
+			name:    "unknown",
+			values:  map[string]string{"owner": "me", "color": "red"},
+			wantErr: `unknown variable "color"`,
+		},
+		{
+			name:    "quote",
+			values:  map[string]string{"owner": "me", "app_name": `My "App"`},
+			wantErr: `invalid value "My \"App\"" for app_name`,
+		},
+		{
+			name:    "markup",
+			values:  map[string]string{"owner": "me", "app_name": "<b>App</b>"},
+			wantErr: "invalid value",
+		},
+		{
+			name:    "partial match",
+			values:  map[string]string{"owner": "me", "port": "80a"},
+			wantErr: `invalid value "80a" for port`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := config.Resolve(tt.values, builtin)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigInvalidPattern(t *testing.T) {
+	template := fstest.MapFS{
+		ConfigFile: {Data: []byte(`{"variables": [{"name": "port", "replaces": "8080", "pattern": "[0-9"}]}`)},
+	}
+	if _, err := LoadConfig(template); err == nil || !strings.Contains(err.Error(), "variable port: invalid pattern") {
+		t.Errorf("got error %v, want invalid pattern", err)
+	}
+}
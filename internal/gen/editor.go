@@ -0,0 +1,31 @@
+package gen
+
+import (
+	"github.com/gofs-cli/gofs/internal/editor"
+)
+
+// renderEditors calls fn with the configuration files of Editors, merged
+// with the rendered template files of the same path in held.
+func (p *Parser) renderEditors(held map[string]File, fn func(f File) error) error {
+	rendered := NewMemFS()
+	for _, f := range held {
+		if err := rendered.WriteFile(f.Path, f.Content, f.Mode); err != nil {
+			return err
+		}
+	}
+	files, err := editor.Files(rendered, p.NewModName, p.Editors)
+	if err != nil {
+		return err
+	}
+	for _, e := range files {
+		f, ok := held[e.Path]
+		if !ok {
+			f = File{Path: e.Path, Mode: fileMode}
+		}
+		f.Content = e.Content
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
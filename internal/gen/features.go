@@ -0,0 +1,297 @@
+package gen
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Feature is an optional part of a template that can be left out at
+// generation time, e.g. a database connector.
+//
+// The files of an excluded feature are not generated, and the template
+// files referring to them are pruned: imports of its go packages are
+// removed, along with its go.mod requirements, docker compose services and
+// .env.example variables. Go code should depend on a feature only through
+// a blank import, e.g. a package registering itself in init, so the code
+// still builds once the import is removed.
+type Feature struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Default features are generated unless excluded.
+	Default bool `json:"default,omitempty"`
+	// Files are the files of the feature, matched like Variable.Files.
+	Files []string `json:"files,omitempty"`
+	// Modules are the go.mod requirements only used by the feature.
+	Modules []string `json:"modules,omitempty"`
+	// Services are the docker compose services only used by the feature.
+	Services []string `json:"services,omitempty"`
+	// Env are the .env.example variables only used by the feature.
+	Env []string `json:"env,omitempty"`
+}
+
+// Feature returns the declared feature with the name.
+func (c *Config) Feature(name string) (Feature, bool) {
+	for _, f := range c.Features {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Feature{}, false
+}
+
+// DefaultFeatures returns the names of the default features.
+func (c *Config) DefaultFeatures() []string {
+	var names []string
+	for _, f := range c.Features {
+		if f.Default {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// SelectFeatures returns the default features with the with features added
+// and the without features removed.
+func (c *Config) SelectFeatures(with, without []string) ([]string, error) {
+	for _, name := range slices.Concat(with, without) {
+		if _, ok := c.Feature(name); !ok {
+			return nil, fmt.Errorf("unknown feature %q", name)
+		}
+		if slices.Contains(with, name) && slices.Contains(without, name) {
+			return nil, fmt.Errorf("feature %q is both selected and excluded", name)
+		}
+	}
+	// not nil, so excluding every feature is not mistaken for the defaults
+	selected := []string{}
+	for _, f := range c.Features {
+		if (f.Default || slices.Contains(with, f.Name)) && !slices.Contains(without, f.Name) {
+			selected = append(selected, f.Name)
+		}
+	}
+	return selected, nil
+}
+
+// selectedFeatures returns Parser.Features, or the default features when
+// Features is nil.
+func (p *Parser) selectedFeatures() []string {
+	if p.Features == nil && p.Config != nil {
+		return p.Config.DefaultFeatures()
+	}
+	return p.Features
+}
+
+// excludedFeatures returns the features that are not selected.
+func (p *Parser) excludedFeatures() []Feature {
+	if p.Config == nil {
+		return nil
+	}
+	selected := p.selectedFeatures()
+	var excluded []Feature
+	for _, f := range p.Config.Features {
+		if !slices.Contains(selected, f.Name) {
+			excluded = append(excluded, f)
+		}
+	}
+	return excluded
+}
+
+// pruning holds what is removed from the generated files for the excluded
+// features and the files left out with Parser.Exclude or IgnoreFile.
+type pruning struct {
+	features []Feature
+	exclude  []string
+	ignore   Ignore
+	// packages are the import paths, in the template module, of the go
+	// packages that are no longer generated.
+	packages map[string]bool
+	modules  []string
+	services []string
+	env      []string
+}
+
+func (p *Parser) newPruning() (*pruning, error) {
+	pr := &pruning{
+		features: p.excludedFeatures(),
+		exclude:  p.Exclude,
+		packages: map[string]bool{},
+	}
+	var err error
+	if pr.ignore, err = LoadIgnore(p.Template); err != nil {
+		return nil, err
+	}
+	if len(pr.features) == 0 && len(pr.exclude) == 0 && len(pr.ignore) == 0 {
+		return pr, nil
+	}
+	for _, f := range pr.features {
+		pr.modules = append(pr.modules, f.Modules...)
+		pr.services = append(pr.services, f.Services...)
+		pr.env = append(pr.env, f.Env...)
+	}
+
+	// a package is removed when none of its go files are generated
+	kept := map[string]bool{}
+	removed := map[string]bool{}
+	err = fs.WalkDir(p.Template, p.TemplateRoot, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(name, ".go") || name == "folder.go" {
+			return err
+		}
+		dir := path.Dir(name)
+		if pr.excludes(name) {
+			removed[dir] = true
+		} else {
+			kept[dir] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for dir := range removed {
+		if !kept[dir] {
+			pr.packages[path.Join(p.CurrentModName, dir)] = true
+		}
+	}
+	return pr, nil
+}
+
+// excludes reports whether the template file belongs to an excluded feature
+// or is left out otherwise.
+func (pr *pruning) excludes(name string) bool {
+	return slices.ContainsFunc(pr.features, func(f Feature) bool {
+		return matchAny(f.Files, name)
+	}) || matchAny(pr.exclude, name) || pr.ignore.Ignores(name)
+}
+
+// removeImports deletes the imports of removed packages from a go file that
+// still imports the template module.
+func (pr *pruning) removeImports(fset *token.FileSet, file *ast.File) {
+	for _, imp := range slices.Clone(file.Imports) {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || !pr.packages[p] {
+			continue
+		}
+		name := ""
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		astutil.DeleteNamedImport(fset, file, name, p)
+	}
+}
+
+// pruneMod drops the requirements of excluded features.
+func (pr *pruning) pruneMod(file *modfile.File) error {
+	if len(pr.modules) == 0 {
+		return nil
+	}
+	for _, m := range pr.modules {
+		if err := file.DropRequire(m); err != nil {
+			return err
+		}
+	}
+	file.Cleanup()
+	return nil
+}
+
+// pruneSum drops the go.sum lines of the modules of excluded features.
+func (pr *pruning) pruneSum(b []byte) []byte {
+	if len(pr.modules) == 0 {
+		return b
+	}
+	return filterLines(b, func(line string) bool {
+		mod, _, _ := strings.Cut(line, " ")
+		return !slices.Contains(pr.modules, mod)
+	})
+}
+
+var envLineRe = regexp.MustCompile(`^\s*#?\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*=`)
+
+// pruneEnv drops the variables of excluded features from an env file,
+// including commented out variables.
+func (pr *pruning) pruneEnv(b []byte) []byte {
+	if len(pr.env) == 0 {
+		return b
+	}
+	return filterLines(b, func(line string) bool {
+		m := envLineRe.FindStringSubmatch(line)
+		return m == nil || !slices.Contains(pr.env, m[1])
+	})
+}
+
+// pruneCompose drops the services of excluded features from a docker
+// compose file. Services are found by indentation below the top level
+// services key.
+func (pr *pruning) pruneCompose(b []byte) []byte {
+	if len(pr.services) == 0 {
+		return b
+	}
+	inServices := false
+	serviceIndent := -1
+	dropping := false
+	kept := false
+	out := filterLines(b, func(line string) bool {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			return !dropping
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == 0 {
+			inServices = trimmed == "services:"
+			dropping = false
+			return true
+		}
+		if !inServices {
+			return true
+		}
+		if serviceIndent < 0 {
+			serviceIndent = indent
+		}
+		if indent == serviceIndent {
+			name := strings.TrimSuffix(trimmed, ":")
+			dropping = strings.HasSuffix(trimmed, ":") && slices.Contains(pr.services, name)
+			kept = kept || !dropping
+		}
+		return !dropping
+	})
+	if !kept {
+		// keep the file valid without any services
+		out = bytes.Replace(out, []byte("services:\n"), []byte("services: {}\n"), 1)
+	}
+	return out
+}
+
+func filterLines(b []byte, keep func(line string) bool) []byte {
+	var out bytes.Buffer
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(nil, len(b)+1)
+	for sc.Scan() {
+		if keep(sc.Text()) {
+			out.WriteString(sc.Text())
+			out.WriteByte('\n')
+		}
+	}
+	return out.Bytes()
+}
+
+// matchAny reports whether the slash separated path matches any of the
+// patterns, see Variable.Files.
+func matchAny(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+			return strings.HasPrefix(name, dir+"/")
+		}
+		ok, _ := path.Match(pattern, name)
+		return ok
+	})
+}
@@ -0,0 +1,77 @@
+package gen
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+var featureConfig = &Config{
+	Features: []Feature{
+		{Name: "postgres", Default: true, Files: []string{"db/**"}},
+		{Name: "tracing", Default: true, Files: []string{"tracing.go"}},
+		{Name: "extra", Files: []string{"extra.go"}},
+	},
+}
+
+func TestSelectFeatures(t *testing.T) {
+	tests := []struct {
+		name          string
+		with, without []string
+		want          []string
+		wantErr       bool
+	}{
+		{name: "defaults", want: []string{"postgres", "tracing"}},
+		{name: "with", with: []string{"extra"}, want: []string{"postgres", "tracing", "extra"}},
+		{name: "without", without: []string{"tracing"}, want: []string{"postgres"}},
+		{name: "all excluded", without: []string{"postgres", "tracing"}, want: []string{}},
+		{name: "unknown", with: []string{"nope"}, wantErr: true},
+		{name: "both", with: []string{"extra"}, without: []string{"extra"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := featureConfig.SelectFeatures(tt.with, tt.without)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got == nil || !slices.Equal(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderAllFeaturesExcluded(t *testing.T) {
+	selected, err := featureConfig.SelectFeatures(nil, []string{"postgres", "tracing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Parser{
+		CurrentModName: "github.com/org/template",
+		NewModName:     "example.com/app",
+		Template: fstest.MapFS{
+			"go.mod":     {Data: []byte("module github.com/org/template\n\ngo 1.24\n")},
+			"main.go":    {Data: []byte("package main\n\nfunc main() {}\n")},
+			"db/db.go":   {Data: []byte("package db\n")},
+			"tracing.go": {Data: []byte("package main\n")},
+			"extra.go":   {Data: []byte("package main\n")},
+		},
+		TemplateRoot: ".",
+		Config:       featureConfig,
+		Features:     selected,
+	}
+	var rendered []string
+	err = p.Render(func(f File) error {
+		rendered = append(rendered, f.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"go.mod", "main.go"}; !slices.Equal(rendered, want) {
+		t.Errorf("rendered %v, want %v", rendered, want)
+	}
+}
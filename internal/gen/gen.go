@@ -1,228 +1,538 @@
 package gen
 
 import (
-	"embed"
-	"encoding/json"
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"go/ast"
 	"go/format"
 	"go/parser"
 	"go/token"
-	"io"
+	"go/version"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
 	templParser "github.com/a-h/templ/parser/v2"
 	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
 	"golang.org/x/tools/go/ast/astutil"
 
+	"github.com/gofs-cli/gofs/internal/editor"
 	"github.com/gofs-cli/gofs/internal/vscode"
 )
 
+// Module path rewrites applied to generated files, see File.Rewrite.
+const (
+	RewriteMod    = "go.mod"
+	RewriteGo     = "go imports"
+	RewriteTempl  = "templ imports"
+	RewriteVscode = "vscode settings"
+	// RewriteText is the module path in other text files, e.g. the local
+	// import prefixes in .golangci.yml or go build flags in a Dockerfile.
+	RewriteText = "module path"
+)
+
 type Parser struct {
 	// DirPath is the path to the folder to parse.
 	// This should be a directory containing the go files to parse.
 	DirPath        string
 	CurrentModName string
 	NewModName     string
-	Template       embed.FS
+	Template       fs.FS
 	TemplateRoot   string
+	// Config is the template configuration, see LoadConfig.
+	Config *Config
+	// Vars are the values of the template variables, see Config.Resolve.
+	// No variables are substituted when nil.
+	Vars map[string]string
+	// Features are the names of the template features to generate, see
+	// Config.SelectFeatures. The default features are generated when nil.
+	Features []string
+	// Exclude are the template files not to generate, matched like
+	// Variable.Files, in addition to the files ignored by IgnoreFile.
+	Exclude []string
+	// Merge merges the template into the existing files of DirPath instead
+	// of overwriting them. Conflicting changes are written as Conflict.
+	Merge    bool
+	Conflict string
+	// Base are the files previously generated, the base of a three-way merge.
+	// Without a base every difference to an existing file is a conflict.
+	Base fs.FS
+	// Output receives the generated files instead of DirPath when not nil,
+	// e.g. a MemFS to compare the template with DirPath. Existing files are
+	// then read from Output.
+	Output Output
+	// Manifest is written to ManifestFile along with a snapshot of the
+	// template by Parse, with the module, variables and features filled in.
+	// Nothing is written to GofsDir when nil.
+	Manifest *Manifest
+	// Editors are the editors configured in the generated project, see
+	// editor.Files. Their configuration is merged into the template files
+	// of the same path.
+	Editors []string
+	// GoVersion lowers the go directive of the generated go.mod files to it
+	// when they require a newer go, e.g. the installed go, and drops their
+	// toolchain directive when it names a newer go. Older go directives are
+	// left as they are.
+	GoVersion string
+}
+
+// File is a file rendered from the template.
+type File struct {
+	// Path is the slash separated path relative to DirPath.
+	Path    string
+	Content []byte
+	Mode    fs.FileMode
+	// Rewrite is the module path rewrite applied to the file, empty when
+	// the file is copied verbatim.
+	Rewrite string
+	// Vars are the names of the variables substituted in the file.
+	Vars []string
 }
 
-func NewParser(dirPath, defaultModuleName, newModuleName string, template embed.FS) (*Parser, error) {
+// NewParser returns a parser generating the template into dirPath. When
+// dirPath already contains a go module, the template is merged into it.
+func NewParser(dirPath, defaultModuleName, newModuleName string, template fs.FS) (*Parser, error) {
+	if err := module.CheckImportPath(newModuleName); err != nil {
+		return nil, fmt.Errorf("invalid module name: %w", err)
+	}
 	// Return an error if the directory is already contains a .gofs folder. Do not overwrite.
-	if _, err := os.Stat(filepath.Join(dirPath, ".gofs")); !os.IsNotExist(err) {
+	if _, err := os.Stat(filepath.Join(dirPath, GofsDir)); !os.IsNotExist(err) {
 		return nil, errors.New("gofs already initialized")
 	}
 
-	// If the directory already contains a go module, only create the .gofs folder. Do not overwrite the go module.
-	if _, err := os.Stat(filepath.Join(dirPath, "go.mod")); !os.IsNotExist(err) {
-		return &Parser{
-			DirPath:        dirPath,
-			CurrentModName: defaultModuleName,
-			NewModName:     newModuleName,
-			Template:       template,
-			TemplateRoot:   ".gofs",
-		}, nil
+	config, err := LoadConfig(template)
+	if err != nil {
+		return nil, err
 	}
 
-	return &Parser{
+	p := &Parser{
 		DirPath:        dirPath,
 		CurrentModName: defaultModuleName,
 		NewModName:     newModuleName,
 		Template:       template,
 		TemplateRoot:   ".",
-	}, nil
-}
+		Config:         config,
+		Conflict:       ConflictMarkers,
+	}
 
-func (p *Parser) copyFile(path string, src fs.File) error {
-	dst, err := os.Create(filepath.Join(p.DirPath, path))
+	// If the directory already contains a go module, merge into it. The
+	// module keeps its path, so it has to be the new module.
+	b, err := os.ReadFile(filepath.Join(dirPath, "go.mod"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return p, nil
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer dst.Close()
+	if mod := modfile.ModulePath(b); mod != newModuleName {
+		return nil, fmt.Errorf("%s already contains module %s, not %s", dirPath, mod, newModuleName)
+	}
+	p.Merge = true
+	return p, nil
+}
 
-	_, err = io.Copy(dst, src)
-	return err
+// Parse generates the template into DirPath and returns what happened to
+// every file. The files are staged and only moved into DirPath once the
+// whole template is generated, so DirPath is left as it was when generation
+// fails.
+func (p *Parser) Parse() ([]Result, error) {
+	return p.ParseContext(context.Background())
 }
 
-func (p *Parser) Parse() error {
-	return fs.WalkDir(p.Template, p.TemplateRoot, func(path string, d fs.DirEntry, err error) error {
+// ParseContext is like Parse but stops, leaving DirPath as it was, when ctx
+// is done before the files are moved into DirPath.
+func (p *Parser) ParseContext(ctx context.Context) ([]Result, error) {
+	var out writer = p.Output
+	var s *staging
+	if p.Output == nil {
+		var err error
+		s, err = newStaging(p.DirPath)
 		if err != nil {
+			return nil, err
+		}
+		defer s.cleanup()
+		out = s
+	}
+	var results []Result
+	hashes := map[string]string{}
+	err := p.Render(func(f File) error {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
+		hashes[f.Path] = Hash(f.Content)
+		resolved, status, err := p.resolve(f)
+		if err != nil {
+			return err
+		}
+		if resolved == nil {
+			results = append(results, Result{Path: f.Path, Status: status})
+			return nil
+		}
+		results = append(results, Result{Path: resolved.Path, Status: status})
+		return out.WriteFile(resolved.Path, resolved.Content, resolved.Mode)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if p.Manifest != nil {
+		if err := p.writeManifest(out, hashes); err != nil {
+			return nil, err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s != nil {
+		if err := s.commit(); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
 
+// Render walks the template and calls fn with every file to generate, with
+// the module path rewritten. Nothing is written to DirPath.
+func (p *Parser) Render(fn func(f File) error) error {
+	pr, err := p.newPruning()
+	if err != nil {
+		return err
+	}
+	// the template files configuring the editors are held back and merged
+	// with the editor configuration once the template is rendered
+	editorPaths := editor.Paths(p.Editors)
+	held := map[string]File{}
+	err = fs.WalkDir(p.Template, p.TemplateRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
 		if d.IsDir() {
-			err := os.MkdirAll(filepath.Join(p.DirPath, path), 0o777)
-			if err != nil {
-				return err
+			// skip the git repository of templates read from a checkout
+			if d.Name() == ".git" {
+				return fs.SkipDir
 			}
-		} else {
-			src, err := p.Template.Open(path)
-			if err != nil {
-				return err
-			}
-			defer src.Close()
-
-			switch {
-			case strings.HasSuffix(path, ".mod"):
-				err := p.updateMod(path, src, p.NewModName)
-				if err != nil {
-					return err
-				}
-			case path == "folder.go":
-				// skip folder.go
-			case strings.HasSuffix(path, ".go"):
-				err := p.updateFile(path, src, p.CurrentModName, p.NewModName)
-				if err != nil {
-					return err
-				}
-			case strings.HasSuffix(path, ".templ"):
-				err := p.updateTempl(path, src)
-				if err != nil {
-					return err
-				}
-			case path == ".vscode/settings.json":
-				err := p.updateVscodeSettings(path, src)
-				if err != nil {
-					return err
-				}
-			case path == "scripts/air_build.sh":
-				err = p.copyFile(path, src)
-				if err != nil {
-					return err
-				}
-				os.Chmod(filepath.Join(p.DirPath, path), 0o755)
-			default:
-				err = p.copyFile(path, src)
-				if err != nil {
-					return err
-				}
+			return nil
+		}
+		if path == "folder.go" || path == ConfigFile || path == IgnoreFile {
+			// skip folder.go and the template configuration
+			return nil
+		}
+		if pr.excludes(path) {
+			return nil
+		}
+
+		b, err := fs.ReadFile(p.Template, path)
+		if err != nil {
+			return err
+		}
+		f := File{Path: path, Mode: p.modeOf(path, d, b)}
+
+		switch {
+		case strings.HasSuffix(path, ".mod"):
+			f.Content, err = p.updateMod(path, b, p.NewModName, pr)
+			f.Rewrite = RewriteMod
+		case strings.HasSuffix(path, ".go"):
+			f.Content, err = p.updateFile(b, p.CurrentModName, p.NewModName, pr)
+			f.Rewrite = RewriteGo
+		case strings.HasSuffix(path, ".templ"):
+			f.Content, err = p.updateTempl(b)
+			f.Rewrite = RewriteTempl
+		case path == ".vscode/settings.json":
+			f.Content, err = p.updateVscodeSettings(b)
+			f.Rewrite = RewriteVscode
+		case isBase(path, "go.sum"):
+			f.Content = pr.pruneSum(b)
+		case isBase(path, ".env.example"):
+			f.Content = pr.pruneEnv(b)
+		case isBase(path, "docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"):
+			f.Content = pr.pruneCompose(b)
+		default:
+			f.Content = b
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if f.Rewrite == "" && isText(path) {
+			if b, ok := replaceModule(f.Content, p.CurrentModName, p.NewModName); ok {
+				f.Content = b
+				f.Rewrite = RewriteText
 			}
 		}
-		return nil
+		p.substituteVars(&f)
+		if len(p.Editors) > 0 && slices.Contains(editorPaths, f.Path) {
+			held[f.Path] = f
+			return nil
+		}
+		return fn(f)
 	})
+	if err != nil || len(p.Editors) == 0 {
+		return err
+	}
+	return p.renderEditors(held, fn)
 }
 
-func (p *Parser) updateMod(path string, src fs.File, modName string) error {
-	bytes, err := io.ReadAll(src)
-	if err != nil {
-		return err
+// Permissions of generated files and directories, reduced by the umask.
+const (
+	fileMode fs.FileMode = 0o644
+	execMode fs.FileMode = 0o755
+	dirMode  fs.FileMode = 0o755
+)
+
+// modeOf returns execMode for the template files declared executable in
+// the configuration, files with execute permission in the template, e.g. a
+// template directory, and scripts starting with #!. Other files get
+// fileMode.
+func (p *Parser) modeOf(name string, d fs.DirEntry, b []byte) fs.FileMode {
+	if p.Config != nil && matchAny(p.Config.Executable, name) {
+		return execMode
+	}
+	if info, err := d.Info(); err == nil && info.Mode()&0o111 != 0 {
+		return execMode
 	}
+	if bytes.HasPrefix(b, []byte("#!")) {
+		return execMode
+	}
+	return fileMode
+}
 
-	file, err := modfile.Parse(path, bytes, nil)
-	if err != nil {
-		return err
+// substituteVars replaces the text of every template variable that applies
+// to the file with its value.
+func (p *Parser) substituteVars(f *File) {
+	if p.Config == nil || p.Vars == nil {
+		return
+	}
+	for _, v := range p.Config.Variables {
+		value, ok := p.Vars[v.Name]
+		if !ok || !v.matches(f.Path) || !bytes.Contains(f.Content, []byte(v.Replaces)) {
+			continue
+		}
+		f.Content = bytes.ReplaceAll(f.Content, []byte(v.Replaces), []byte(value))
+		f.Vars = append(f.Vars, v.Name)
 	}
-	file.AddModuleStmt(modName)
+}
 
-	newBytes := modfile.Format(file.Syntax)
-	return os.WriteFile(filepath.Join(p.DirPath, path), newBytes, 0o644)
+// isBase reports whether the last element of the slash separated path is
+// one of the names.
+func isBase(p string, names ...string) bool {
+	return slices.Contains(names, p[strings.LastIndex(p, "/")+1:])
 }
 
-func (p *Parser) updateFile(path string, src fs.File, oldModName, newModName string) error {
-	fset := token.NewFileSet()
-	bytes, err := io.ReadAll(src)
+// updateMod moves the go.mod of the template to the new module. Nested
+// modules of the template move along, e.g. tools/go.mod, and so do the
+// requirements, replace and tool directives referring to the template
+// module. Local replacements pointing outside the template are dropped, as
+// the generated project does not have their directories.
+func (p *Parser) updateMod(path string, b []byte, modName string, pr *pruning) ([]byte, error) {
+	file, err := modfile.Parse(path, b, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	file, err := parser.ParseFile(fset, "", bytes, parser.ParseComments)
-	if err != nil {
-		return err
+	if file.Module == nil {
+		file.AddModuleStmt(modName)
+	} else if rest, ok := cutModule(file.Module.Mod.Path, p.CurrentModName); ok {
+		file.AddModuleStmt(modName + rest)
 	}
-
-	imports := astutil.Imports(fset, file)
-	for _, para := range imports {
-		for _, imp := range para {
-			oldPath, err := strconv.Unquote(imp.Path.Value)
-			if err != nil {
-				return err
+	for _, r := range file.Require {
+		r := *r
+		if rest, ok := cutModule(r.Mod.Path, p.CurrentModName); ok {
+			if err := file.DropRequire(r.Mod.Path); err != nil {
+				return nil, err
 			}
-			if strings.Contains(oldPath, oldModName) {
-				newPath := strings.Replace(oldPath, oldModName, newModName, 1)
-				rewritten := astutil.RewriteImport(fset, file, oldPath, newPath)
-				if !rewritten {
-					return err
-				}
+			file.AddNewRequire(modName+rest, r.Mod.Version, r.Indirect)
+		}
+	}
+	for _, r := range file.Replace {
+		// the dropped directive is cleared
+		r := *r
+		oldPath := r.Old.Path
+		if rest, ok := cutModule(oldPath, p.CurrentModName); ok {
+			oldPath = modName + rest
+		}
+		outside := modfile.IsDirectoryPath(r.New.Path) && !inTemplate(path, r.New.Path)
+		if oldPath == r.Old.Path && !outside {
+			continue
+		}
+		if err := file.DropReplace(r.Old.Path, r.Old.Version); err != nil {
+			return nil, err
+		}
+		if !outside {
+			if err := file.AddReplace(oldPath, r.Old.Version, r.New.Path, r.New.Version); err != nil {
+				return nil, err
 			}
 		}
 	}
+	for _, t := range file.Tool {
+		tool := t.Path
+		if rest, ok := cutModule(tool, p.CurrentModName); ok {
+			if err := file.DropTool(tool); err != nil {
+				return nil, err
+			}
+			if err := file.AddTool(modName + rest); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if p.GoVersion != "" {
+		local := "go" + p.GoVersion
+		if file.Go != nil && version.Compare("go"+file.Go.Version, local) > 0 {
+			if err := file.AddGoStmt(p.GoVersion); err != nil {
+				return nil, err
+			}
+		}
+		if file.Toolchain != nil && version.Compare(file.Toolchain.Name, local) > 0 {
+			file.DropToolchainStmt()
+		}
+	}
+	if err := pr.pruneMod(file); err != nil {
+		return nil, err
+	}
+	file.Cleanup()
+
+	return modfile.Format(file.Syntax), nil
+}
+
+// inTemplate reports whether the directory of a replace directive in the
+// go.mod at the slash separated path modPath is inside the template.
+func inTemplate(modPath, dir string) bool {
+	dir = filepath.FromSlash(dir)
+	if filepath.IsAbs(dir) {
+		return false
+	}
+	return filepath.IsLocal(filepath.Join(filepath.Dir(filepath.FromSlash(modPath)), dir))
+}
+
+// cutModule returns the rest of the import or module path p after the
+// module path mod, and whether p is mod or one of its packages.
+func cutModule(p, mod string) (string, bool) {
+	rest, ok := strings.CutPrefix(p, mod)
+	if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+		return "", false
+	}
+	return rest, true
+}
 
-	dst, err := os.Create(filepath.Join(p.DirPath, path))
+func (p *Parser) updateFile(b []byte, oldModName, newModName string, pr *pruning) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", b, parser.ParseComments)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	pr.removeImports(fset, file)
+	if _, err := rewriteImports(fset, file, oldModName, newModName); err != nil {
+		return nil, err
 	}
-	defer dst.Close()
 
-	return format.Node(dst, fset, file)
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
-func (p *Parser) updateVscodeSettings(path string, src fs.File) error {
+// updateVscodeSettings sets the new module as the gopls local import prefix.
+// The other settings of the template are kept, and the gopls defaults of
+// gofs are added when missing.
+func (p *Parser) updateVscodeSettings(b []byte) ([]byte, error) {
 	set := vscode.Settings{}
-	err := json.NewDecoder(src).Decode(&set)
+	err := vscode.Unmarshal(b, &set)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	set.SetGopls(vscode.Gopls{
-		FormattingLocal:   p.NewModName,
-		FormattingGofumpt: true,
-		BuildBuildFlags:   []string{"-tags=unit,gendata"},
+	set.SetGoplsLocal(p.NewModName)
+	set.Merge(vscode.Settings{
+		"gopls": map[string]any{
+			"formatting.gofumpt": true,
+			"build.buildFlags":   []string{"-tags=unit,gendata"},
+		},
 	})
-	dst, err := os.Create(filepath.Join(p.DirPath, path))
-	if err != nil {
-		return err
-	}
-	defer dst.Close()
-	enc := json.NewEncoder(dst)
-	enc.SetIndent("", "  ")
-	return enc.Encode(set)
+	return vscode.Marshal(set)
 }
 
-func (p *Parser) updateTempl(path string, file fs.File) error {
-	b, err := io.ReadAll(file)
-	if err != nil {
-		return err
+// rewriteImports rewrites the imports of the old module and its packages to
+// the new module. Import names are kept, so aliased and dot imports keep
+// working. It reports whether any import was rewritten.
+func rewriteImports(fset *token.FileSet, file *ast.File, oldModName, newModName string) (bool, error) {
+	rewritten := false
+	for _, imp := range file.Imports {
+		oldPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			return false, err
+		}
+		rest, ok := cutModule(oldPath, oldModName)
+		if !ok {
+			continue
+		}
+		if !astutil.RewriteImport(fset, file, oldPath, newModName+rest) {
+			return false, fmt.Errorf("could not rewrite import %q", oldPath)
+		}
+		rewritten = true
 	}
+	return rewritten, nil
+}
+
+// updateTempl rewrites the imports of the templ file like updateFile. The Go
+// code between templates is parsed as a Go file, so imports are found after
+// comments and in any Go block, and other code is left alone. Only the
+// rewritten Go code changes, the rest of the file keeps its formatting so
+// the positions in the _templ.go file generated from it stay valid.
+func (p *Parser) updateTempl(b []byte) ([]byte, error) {
 	t, err := templParser.ParseString(string(b))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	for i, n := range t.Nodes {
-		switch n := n.(type) {
-		case *templParser.TemplateFileGoExpression:
-			if strings.HasPrefix(n.Expression.Value, "import") {
-				node := n
-				node.Expression.Value = strings.ReplaceAll(node.Expression.Value, p.CurrentModName, p.NewModName)
-				t.Nodes[i] = node
-			}
+	var out bytes.Buffer
+	last := 0
+	for _, n := range t.Nodes {
+		n, ok := n.(*templParser.TemplateFileGoExpression)
+		if !ok {
+			continue
+		}
+		value, err := rewriteGoBlock(n.Expression.Value, p.CurrentModName, p.NewModName)
+		if err != nil {
+			return nil, err
+		}
+		if value == n.Expression.Value {
+			continue
+		}
+		// the value is the trimmed Go code of the range
+		r := n.Expression.Range
+		i := bytes.Index(b[r.From.Index:r.To.Index], []byte(n.Expression.Value))
+		if i < 0 {
+			return nil, fmt.Errorf("Go code not found at %s", r.From.String())
 		}
+		start := int(r.From.Index) + i
+		out.Write(b[last:start])
+		out.WriteString(value)
+		last = start + len(n.Expression.Value)
 	}
-	dst, err := os.Create(filepath.Join(p.DirPath, path))
+	if last == 0 {
+		return b, nil
+	}
+	out.Write(b[last:])
+	return out.Bytes(), nil
+}
+
+// rewriteGoBlock rewrites the imports of Go declarations in a templ file. The
+// block is returned as is when it imports nothing of the old module.
+func rewriteGoBlock(src, oldModName, newModName string) (string, error) {
+	const pkg = "package p\n"
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", pkg+src, parser.ParseComments)
 	if err != nil {
-		return err
+		return "", err
+	}
+	rewritten, err := rewriteImports(fset, file, oldModName, newModName)
+	if err != nil || !rewritten {
+		return src, err
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return "", err
 	}
-	defer dst.Close()
-	return t.Write(dst)
+	return strings.TrimSpace(strings.TrimPrefix(buf.String(), pkg)), nil
 }
@@ -0,0 +1,144 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewParserModuleName(t *testing.T) {
+	template := fstest.MapFS{
+		"go.mod": {Data: []byte("module github.com/org/template\n\ngo 1.24\n")},
+	}
+	tests := []struct {
+		name    string
+		module  string
+		wantErr bool
+	}{
+		{name: "domain", module: "github.com/user/app"},
+		{name: "dotless", module: "mymodule"},
+		{name: "dotless nested", module: "mymodule/sub"},
+		{name: "space", module: "my module", wantErr: true},
+		{name: "empty", module: "", wantErr: true},
+		{name: "leading slash", module: "/abs/path", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "app")
+			_, err := NewParser(dir, "github.com/org/template", tt.module, template)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewParser(%q) error = %v, want error %v", tt.module, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateMod(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		goVersion string
+		mod       string
+		want      string
+	}{
+		{
+			name: "requires",
+			path: "go.mod",
+			mod:  "module github.com/org/template\n\ngo 1.24\n\nrequire (\n\tgithub.com/org/template/lib v1.0.0\n\tgithub.com/other/lib v1.2.0\n)\n",
+			want: "module example.com/app\n\ngo 1.24\n\nrequire (\n\tgithub.com/other/lib v1.2.0\n\texample.com/app/lib v1.0.0\n)\n",
+		},
+		{
+			name: "replace inside the template",
+			path: "go.mod",
+			mod:  "module github.com/org/template\n\ngo 1.24\n\nreplace github.com/org/template/tools => ./tools\n",
+			want: "module example.com/app\n\ngo 1.24\n\nreplace example.com/app/tools => ./tools\n",
+		},
+		{
+			name: "replace outside the template",
+			path: "go.mod",
+			mod:  "module github.com/org/template\n\ngo 1.24\n\nreplace github.com/other/lib => ../other\n\nreplace github.com/other/pinned => github.com/fork/pinned v1.0.0\n",
+			want: "module example.com/app\n\ngo 1.24\n\nreplace github.com/other/pinned => github.com/fork/pinned v1.0.0\n",
+		},
+		{
+			name: "tool",
+			path: "go.mod",
+			mod:  "module github.com/org/template\n\ngo 1.24\n\ntool (\n\tgithub.com/a-h/templ/cmd/templ\n\tgithub.com/org/template/cmd/gen\n)\n",
+			want: "module example.com/app\n\ngo 1.24\n\ntool (\n\texample.com/app/cmd/gen\n\tgithub.com/a-h/templ/cmd/templ\n)\n",
+		},
+		{
+			name: "nested module",
+			path: "tools/go.mod",
+			mod:  "module github.com/org/template/tools\n\ngo 1.24\n\nrequire github.com/org/template v0.0.0\n\nreplace github.com/org/template => ../\n\nreplace github.com/other/lib => ../../other\n",
+			want: "module example.com/app/tools\n\ngo 1.24\n\nrequire example.com/app v0.0.0\n\nreplace example.com/app => ../\n",
+		},
+		{
+			name:      "align go lowers a newer go",
+			path:      "go.mod",
+			goVersion: "1.25.5",
+			mod:       "module github.com/org/template\n\ngo 1.27\n\ntoolchain go1.27.1\n",
+			want:      "module example.com/app\n\ngo 1.25.5\n",
+		},
+		{
+			name:      "align go keeps an older go",
+			path:      "go.mod",
+			goVersion: "1.25.5",
+			mod:       "module github.com/org/template\n\ngo 1.24\n\ntoolchain go1.24.3\n",
+			want:      "module example.com/app\n\ngo 1.24\n\ntoolchain go1.24.3\n",
+		},
+		{
+			name:      "align go drops a newer toolchain",
+			path:      "go.mod",
+			goVersion: "1.25.5",
+			mod:       "module github.com/org/template\n\ngo 1.24\n\ntoolchain go1.27.1\n",
+			want:      "module example.com/app\n\ngo 1.24\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Parser{CurrentModName: "github.com/org/template", GoVersion: tt.goVersion}
+			got, err := p.updateMod(tt.path, []byte(tt.mod), "example.com/app", &pruning{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("updateMod(%s) =\n%s\nwant\n%s", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseModes(t *testing.T) {
+	template := stagingTemplate(map[string]string{
+		ConfigFile:         `{"executable": ["scripts/*.sh"]}`,
+		"scripts/setup.sh": "echo setup\n",
+		"run":              "#!/bin/sh\necho run\n",
+		"README.md":        "# App\n",
+	})
+	template["bin/tool"] = &fstest.MapFile{Data: []byte("tool\n"), Mode: 0o755}
+	dir := filepath.Join(t.TempDir(), "app")
+	p, err := NewParser(dir, templateModule, "example.com/app", template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]bool{
+		"scripts/setup.sh": true,
+		"run":              true,
+		"bin/tool":         true,
+		"README.md":        false,
+		"go.mod":           false,
+	}
+	for name, exec := range tests {
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the umask may clear the group and other bits, not the owner ones
+		if got := info.Mode().Perm()&0o100 != 0; got != exec || (!exec && info.Mode().Perm()&0o111 != 0) {
+			t.Errorf("%s has mode %v, want executable %v", name, info.Mode().Perm(), exec)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package gen
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile lists the template files that are not generated, e.g. notes
+// for template authors, in gitignore syntax. It is read from the template
+// root by gofs and not generated.
+const IgnoreFile = ".gofsignore"
+
+// Ignore are the patterns of an IgnoreFile.
+type Ignore []ignorePattern
+
+type ignorePattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// LoadIgnore reads IgnoreFile from the template. A template without one
+// ignores nothing.
+func LoadIgnore(template fs.FS) (Ignore, error) {
+	b, err := fs.ReadFile(template, IgnoreFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ig, err := ParseIgnore(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", IgnoreFile, err)
+	}
+	return ig, nil
+}
+
+// ParseIgnore parses patterns in gitignore syntax. Patterns are relative to
+// the template root: a pattern containing a slash other than a trailing one
+// is matched against the whole path, other patterns against every path
+// element. A trailing slash only matches directories, ! re-includes a path
+// excluded by a previous pattern and ** matches any number of directories.
+// [...] matches a character of a class, negated with [!...] or [^...], and
+// a backslash escapes the next character, e.g. \# or \!.
+func ParseIgnore(b []byte) (Ignore, error) {
+	var ig Ignore
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; s.Scan(); n++ {
+		line := trimIgnoreSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var p ignorePattern
+		if line, p.negate = strings.CutPrefix(line, "!"); p.negate && line == "" {
+			continue
+		}
+		line, p.dirOnly = strings.CutSuffix(line, "/")
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+
+		expr := ignoreRegexp(line)
+		if !anchored {
+			expr = "(?:.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q", n, s.Text())
+		}
+		p.re = re
+		ig = append(ig, p)
+	}
+	return ig, s.Err()
+}
+
+// trimIgnoreSpace removes the trailing spaces of a line unless escaped with
+// a backslash.
+func trimIgnoreSpace(line string) string {
+	line = strings.TrimSuffix(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// ignoreRegexp translates a gitignore pattern to a regular expression.
+func ignoreRegexp(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/") && (i == 0 || pattern[i-1] == '/'):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case pattern[i:] == "**" && (i == 0 || pattern[i-1] == '/'):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := classEnd(pattern, i)
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			sb.WriteString(classRegexp(pattern[i+1 : end]))
+			i = end
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// classEnd returns the index of the ] closing the character class starting
+// at pattern[start], -1 when it is not closed. A ] right after the [ or the
+// negating ! or ^ is part of the class, and \ escapes the next character.
+func classEnd(pattern string, start int) int {
+	i := start + 1
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		i++
+	}
+	if i < len(pattern) && pattern[i] == ']' {
+		i++
+	}
+	for ; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// classRegexp translates the character class between the brackets to a
+// regular expression. As a class never matches a slash, a negated class
+// excludes it.
+func classRegexp(class string) string {
+	var sb strings.Builder
+	sb.WriteString("[")
+	if rest, ok := strings.CutPrefix(class, "!"); ok {
+		class = rest
+		sb.WriteString("^/")
+	} else if rest, ok := strings.CutPrefix(class, "^"); ok {
+		class = rest
+		sb.WriteString("^/")
+	}
+	for i := 0; i < len(class); i++ {
+		c := class[i]
+		if c == '\\' && i+1 < len(class) {
+			i++
+			c = class[i]
+			if !isAlnum(c) {
+				sb.WriteByte('\\')
+			}
+		} else if c == '[' || c == ']' || c == '^' || c == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(c)
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// Ignores reports whether the slash separated path of a template file is
+// ignored. As in git, a file in an ignored directory cannot be re-included.
+func (ig Ignore) Ignores(name string) bool {
+	return ig.ignores(name, false)
+}
+
+// IgnoresDir reports whether the slash separated path of a directory is
+// ignored, and so everything in it.
+func (ig Ignore) IgnoresDir(name string) bool {
+	return ig.ignores(name, true)
+}
+
+func (ig Ignore) ignores(name string, dir bool) bool {
+	if len(ig) == 0 {
+		return false
+	}
+	for i := range len(name) {
+		if name[i] == '/' && ig.match(name[:i], true) {
+			return true
+		}
+	}
+	return ig.match(name, dir)
+}
+
+// match applies the patterns to a path, the last matching pattern wins.
+func (ig Ignore) match(name string, dir bool) bool {
+	ignored := false
+	for _, p := range ig {
+		if (dir || !p.dirOnly) && p.re.MatchString(name) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,170 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIgnores(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns string
+		ignored  []string
+		kept     []string
+	}{
+		{
+			name:     "name in any directory",
+			patterns: "NOTES.md",
+			ignored:  []string{"NOTES.md", "docs/NOTES.md", "NOTES.md/x.go"},
+			kept:     []string{"README.md", "docs/NOTES.md.bak"},
+		},
+		{
+			name:     "wildcards",
+			patterns: "*.tmp\nv?.txt",
+			ignored:  []string{"a.tmp", "x/y/b.tmp", "v1.txt"},
+			kept:     []string{"a.tmpl", "v10.txt", "v/.txt"},
+		},
+		{
+			name:     "anchored",
+			patterns: "/build\ndocs/*.md",
+			ignored:  []string{"build", "build/out.txt", "docs/a.md"},
+			kept:     []string{"cmd/build", "docs/sub/a.md", "other/docs/a.md"},
+		},
+		{
+			name:     "directories only",
+			patterns: "notes/",
+			ignored:  []string{"notes/a.md", "x/notes/b.md"},
+			kept:     []string{"notes", "x/notes"},
+		},
+		{
+			name:     "double star",
+			patterns: "**/fixtures\nassets/**\na/**/z.txt",
+			ignored:  []string{"fixtures", "x/y/fixtures/f.json", "assets/css/a.css", "a/z.txt", "a/b/c/z.txt"},
+			kept:     []string{"assets", "b/a/z.txt"},
+		},
+		{
+			name:     "negation",
+			patterns: "*.md\n!README.md",
+			ignored:  []string{"NOTES.md", "docs/a.md"},
+			kept:     []string{"README.md", "docs/README.md"},
+		},
+		{
+			name:     "no re-include in an ignored directory",
+			patterns: "docs/\n!docs/keep.md",
+			ignored:  []string{"docs/keep.md"},
+		},
+		{
+			name:     "last pattern wins",
+			patterns: "!a.txt\n*.txt",
+			ignored:  []string{"a.txt"},
+		},
+		{
+			name:     "comments and blank lines",
+			patterns: "# notes\n\n   \n!\nfoo",
+			ignored:  []string{"foo"},
+			kept:     []string{"# notes", "notes", "!"},
+		},
+		{
+			name:     "escapes",
+			patterns: "\\#hash\n\\!bang\nstar\\*\nspace\\ \nq\\?",
+			ignored:  []string{"#hash", "!bang", "star*", "space ", "q?"},
+			kept:     []string{"hash", "bang", "starry", "space", "qx"},
+		},
+		{
+			name:     "trailing spaces",
+			patterns: "trail   \r",
+			ignored:  []string{"trail"},
+			kept:     []string{"trail   "},
+		},
+		{
+			name:     "character classes",
+			patterns: "file[0-9].txt\n[Mm]akefile.bak",
+			ignored:  []string{"file1.txt", "Makefile.bak", "makefile.bak"},
+			kept:     []string{"fileA.txt", "file10.txt", "Xakefile.bak"},
+		},
+		{
+			name:     "negated character classes",
+			patterns: "log[!0-9]\ncache[^a]",
+			ignored:  []string{"logs", "logX", "cacheb"},
+			kept:     []string{"log1", "cachea", "cache/x"},
+		},
+		{
+			name:     "classes do not match slashes",
+			patterns: "a[!x]b",
+			kept:     []string{"a/b"},
+		},
+		{
+			name:     "bracket first in class",
+			patterns: "x[]y]z\nw[!]]v",
+			ignored:  []string{"x]z", "xyz", "wav"},
+			kept:     []string{"w]v"},
+		},
+		{
+			name:     "escaped bracket and dash in class",
+			patterns: "c[\\]\\-]d",
+			ignored:  []string{"c]d", "c-d"},
+			kept:     []string{"cxd"},
+		},
+		{
+			name:     "unclosed class",
+			patterns: "a[b",
+			ignored:  []string{"a[b"},
+			kept:     []string{"ab"},
+		},
+		{
+			name:     "regexp characters",
+			patterns: "a+b(c).{d}|e$",
+			ignored:  []string{"a+b(c).{d}|e$"},
+			kept:     []string{"aab(c)x{d}|e"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ig, err := ParseIgnore([]byte(tt.patterns))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range tt.ignored {
+				if !ig.Ignores(name) {
+					t.Errorf("%q not ignored", name)
+				}
+			}
+			for _, name := range tt.kept {
+				if ig.Ignores(name) {
+					t.Errorf("%q ignored", name)
+				}
+			}
+		})
+	}
+}
+
+func TestIgnoresDir(t *testing.T) {
+	ig, err := ParseIgnore([]byte("notes/\n/build\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"notes", "x/notes", "build"} {
+		if !ig.IgnoresDir(name) {
+			t.Errorf("directory %q not ignored", name)
+		}
+	}
+	for _, name := range []string{"docs", "x/build"} {
+		if ig.IgnoresDir(name) {
+			t.Errorf("directory %q ignored", name)
+		}
+	}
+}
+
+func TestIgnoreEmpty(t *testing.T) {
+	var ig Ignore
+	if ig.Ignores("anything") {
+		t.Error("empty Ignore ignores a file")
+	}
+}
+
+func TestParseIgnoreInvalid(t *testing.T) {
+	_, err := ParseIgnore([]byte("ok\n[z-a]\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("got error %v, want an error on line 2", err)
+	}
+}
@@ -0,0 +1,151 @@
+package gen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Files gofs keeps in generated projects.
+const (
+	// GofsDir holds the gofs files of a project. A directory containing it
+	// was already generated.
+	GofsDir = ".gofs"
+	// ManifestFile records how the project was generated.
+	ManifestFile = GofsDir + "/manifest.json"
+	// SnapshotDir holds a copy of the template the project was generated
+	// from, the base when upgrading to a newer template.
+	SnapshotDir = GofsDir + "/snapshot"
+)
+
+// Manifest records how a project was generated, see ManifestFile.
+type Manifest struct {
+	// Template is the name of the template.
+	Template string `json:"template"`
+	// TemplateDir is the directory the template was read from, empty for
+	// built in templates.
+	TemplateDir    string `json:"templateDir,omitempty"`
+	TemplateModule string `json:"templateModule"`
+	// TemplateVersion is the version of the template module, empty for
+	// templates read from a directory.
+	TemplateVersion string            `json:"templateVersion,omitempty"`
+	GofsVersion     string            `json:"gofsVersion"`
+	Module          string            `json:"module"`
+	Vars            map[string]string `json:"vars,omitempty"`
+	Features        []string          `json:"features"`
+	// Exclude are the files left out with Parser.Exclude.
+	Exclude []string `json:"exclude,omitempty"`
+	// Editors are the editors configured with Parser.Editors or
+	// "gofs editor setup".
+	Editors []string `json:"editors,omitempty"`
+	// Files maps the generated files to the hex encoded SHA-256 of their
+	// content as generated from the template, before merging into existing
+	// files.
+	Files map[string]string `json:"files"`
+}
+
+// ReadManifest reads the manifest of the project at dir.
+func ReadManifest(dir string) (*Manifest, error) {
+	b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ManifestFile)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s not found, the project was not generated by this version of gofs", ManifestFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", ManifestFile, err)
+	}
+	return &m, nil
+}
+
+// WriteManifest writes the manifest of the project at dir.
+func WriteManifest(dir string, m *Manifest) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, filepath.FromSlash(ManifestFile)), append(b, '\n'), fileMode)
+}
+
+// Snapshot returns the template snapshot of the project at dir.
+func Snapshot(dir string) (fs.FS, error) {
+	snapshot := filepath.Join(dir, filepath.FromSlash(SnapshotDir))
+	if _, err := os.Stat(snapshot); err != nil {
+		return nil, fmt.Errorf("template snapshot not found: %w", err)
+	}
+	return os.DirFS(snapshot), nil
+}
+
+// Hash returns the hex encoded SHA-256 of b, see Manifest.Files.
+func Hash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeManifest stages the manifest with the hashes of the generated files
+// and the template snapshot, replacing the previous snapshot.
+func (p *Parser) writeManifest(w writer, files map[string]string) error {
+	m := *p.Manifest
+	m.TemplateModule = p.CurrentModName
+	m.Module = p.NewModName
+	m.Vars = p.Vars
+	m.Features = p.selectedFeatures()
+	m.Exclude = p.Exclude
+	m.Editors = p.Editors
+	if m.Features == nil {
+		m.Features = []string{}
+	}
+	m.Files = files
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := w.WriteFile(ManifestFile, append(b, '\n'), fileMode); err != nil {
+		return err
+	}
+
+	snapshot := map[string]bool{}
+	err = fs.WalkDir(p.Template, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		b, err := fs.ReadFile(p.Template, name)
+		if err != nil {
+			return err
+		}
+		snapshot[name] = true
+		return w.WriteFile(path.Join(SnapshotDir, name), b, p.modeOf(name, d, b))
+	})
+	if err != nil {
+		return err
+	}
+
+	// remove the files of the previous snapshot missing from the template
+	old, err := fs.Sub(p.existing(), SnapshotDir)
+	if err != nil {
+		return err
+	}
+	return fs.WalkDir(old, ".", func(name string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && name == "." {
+			return nil
+		}
+		if err != nil || d.IsDir() || snapshot[name] {
+			return err
+		}
+		return w.Remove(path.Join(SnapshotDir, name))
+	})
+}
@@ -0,0 +1,153 @@
+package gen
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MemFS is an Output keeping the files in memory. Directories are not
+// stored, they exist while they contain files.
+type MemFS struct {
+	files map[string]memFile
+}
+
+type memFile struct {
+	data []byte
+	mode fs.FileMode
+}
+
+// NewMemFS returns an empty MemFS.
+func NewMemFS() MemFS {
+	return MemFS{files: map[string]memFile{}}
+}
+
+func (m MemFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	m.files[name] = memFile{data: bytes.Clone(data), mode: perm.Perm()}
+	return nil
+}
+
+func (m MemFS) Remove(name string) error {
+	delete(m.files, name)
+	return nil
+}
+
+// Names returns the slash separated paths of the files, sorted.
+func (m MemFS) Names() []string {
+	return slices.Sorted(maps.Keys(m.files))
+}
+
+func (m MemFS) ReadFile(name string) ([]byte, error) {
+	f, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return bytes.Clone(f.data), nil
+}
+
+func (m MemFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if f, ok := m.files[name]; ok {
+		info := memInfo{name: path.Base(name), size: int64(len(f.data)), mode: f.mode}
+		return &memOpenFile{info: info, Reader: bytes.NewReader(f.data)}, nil
+	}
+	entries, err := m.ReadDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	info := memInfo{name: path.Base(name), mode: fs.ModeDir | dirMode}
+	return &memOpenDir{info: info, entries: entries}, nil
+}
+
+// ReadDir returns the entries of the directory name, sorted by name.
+func (m MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	entries := map[string]memInfo{}
+	for file, f := range m.files {
+		rest, ok := strings.CutPrefix(file, prefix)
+		if !ok {
+			continue
+		}
+		if elem, _, isDir := strings.Cut(rest, "/"); isDir {
+			entries[elem] = memInfo{name: elem, mode: fs.ModeDir | dirMode}
+		} else {
+			entries[rest] = memInfo{name: rest, size: int64(len(f.data)), mode: f.mode}
+		}
+	}
+	if len(entries) == 0 && name != "." {
+		if _, ok := m.files[name]; ok {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+		}
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	var list []fs.DirEntry
+	for _, elem := range slices.Sorted(maps.Keys(entries)) {
+		list = append(list, entries[elem])
+	}
+	return list, nil
+}
+
+// memInfo describes a file or directory of a MemFS.
+type memInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+}
+
+func (i memInfo) Name() string               { return i.name }
+func (i memInfo) Size() int64                { return i.size }
+func (i memInfo) Mode() fs.FileMode          { return i.mode }
+func (i memInfo) ModTime() time.Time         { return time.Time{} }
+func (i memInfo) IsDir() bool                { return i.mode.IsDir() }
+func (i memInfo) Sys() any                   { return nil }
+func (i memInfo) Type() fs.FileMode          { return i.mode.Type() }
+func (i memInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+type memOpenFile struct {
+	info memInfo
+	*bytes.Reader
+}
+
+func (f *memOpenFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memOpenFile) Close() error               { return nil }
+
+type memOpenDir struct {
+	info    memInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *memOpenDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *memOpenDir) Close() error               { return nil }
+
+func (d *memOpenDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *memOpenDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(rest) {
+		rest = rest[:n]
+	}
+	d.offset += len(rest)
+	return rest, nil
+}
@@ -0,0 +1,73 @@
+package gen
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMemFS(t *testing.T) {
+	m := NewMemFS()
+	files := map[string]string{
+		"go.mod":                      "module example.com/app\n",
+		"cmd/app/main.go":             "package main\n",
+		"internal/ui/index.templ":     "package ui\n",
+		".gofs/snapshot/gofs.json":    "{}\n",
+		"internal/ui/components/a.go": "package components\n",
+	}
+	for name, data := range files {
+		if err := m.WriteFile(name, []byte(data), fileMode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.WriteFile("removed.txt", nil, fileMode); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Remove("removed.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fstest.TestFS(m, "go.mod", "cmd/app/main.go", "internal/ui/index.templ", ".gofs/snapshot/gofs.json"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".gofs/snapshot/gofs.json", "cmd/app/main.go", "go.mod", "internal/ui/components/a.go", "internal/ui/index.templ"}
+	if got := m.Names(); !slices.Equal(got, want) {
+		t.Errorf("Names() = %q, want %q", got, want)
+	}
+
+	// fs.Sub and fs.WalkDir are how the snapshot in an Output is read
+	snapshot, err := fs.Sub(m, SnapshotDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := fs.ReadFile(snapshot, "gofs.json")
+	if err != nil || string(b) != "{}\n" {
+		t.Errorf("snapshot gofs.json = %q, %v", b, err)
+	}
+
+	if _, err := m.ReadFile("removed.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("removed file: got error %v, want %v", err, fs.ErrNotExist)
+	}
+	if _, err := m.Open("internal/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing dir: got error %v, want %v", err, fs.ErrNotExist)
+	}
+	if err := m.WriteFile("../outside", nil, fileMode); err == nil {
+		t.Error("wrote a file outside of the MemFS")
+	}
+}
+
+func TestMemFSWriteCopies(t *testing.T) {
+	m := NewMemFS()
+	data := []byte("a")
+	if err := m.WriteFile("a.txt", data, fileMode); err != nil {
+		t.Fatal(err)
+	}
+	data[0] = 'b'
+	b, _ := m.ReadFile("a.txt")
+	b[0] = 'c'
+	if b, _ := m.ReadFile("a.txt"); string(b) != "a" {
+		t.Errorf("content changed through a caller's slice: %q", b)
+	}
+}
@@ -0,0 +1,173 @@
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"go/version"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+
+	"github.com/gofs-cli/gofs/internal/diff"
+)
+
+// Status of a generated file merged into an existing file, see Parser.Merge.
+const (
+	// StatusMerged files combine the existing file and the template.
+	StatusMerged = "merged"
+	// StatusUnchanged files differ from the template but are kept as they
+	// are, e.g. the template did not change since the base.
+	StatusUnchanged = "unchanged"
+	// StatusSidecar files conflict, the template version is written next to
+	// the existing file with SidecarSuffix.
+	StatusSidecar = "sidecar"
+)
+
+// How conflicting files are written when merging, see Parser.Conflict.
+const (
+	ConflictMarkers = "markers"
+	ConflictSidecar = "sidecar"
+)
+
+// SidecarSuffix is appended to the path of the template version of a
+// conflicting file with ConflictSidecar.
+const SidecarSuffix = ".gofs-new"
+
+// Result is the outcome of generating a file.
+type Result struct {
+	// Path is the slash separated path relative to DirPath of the file
+	// written, with SidecarSuffix for StatusSidecar.
+	Path   string `json:"path"`
+	Status string `json:"status"`
+}
+
+// resolve compares the rendered file with the file in DirPath. It returns
+// the file to write, or nil when there is nothing to write, and the status.
+func (p *Parser) resolve(f File) (*File, string, error) {
+	existing, err := fs.ReadFile(p.existing(), f.Path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return p.resolveMissing(f)
+	case err != nil:
+		return nil, "", err
+	case bytes.Equal(existing, f.Content):
+		return nil, StatusIdentical, nil
+	case !p.Merge:
+		return &f, StatusConflict, nil
+	}
+
+	var base []byte
+	if p.Base != nil {
+		base, err = fs.ReadFile(p.Base, f.Path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, "", err
+		}
+	}
+
+	status := StatusMerged
+	switch {
+	case f.Path == "go.mod":
+		f.Content, err = mergeMod(existing, f.Content)
+		if err != nil {
+			return nil, "", err
+		}
+	case isBase(f.Path, "go.sum"):
+		f.Content = mergeSum(existing, f.Content)
+	case base != nil && bytes.Equal(existing, base):
+		// only the template changed
+	case base != nil && bytes.Equal(f.Content, base):
+		// only the existing file changed
+		return nil, StatusUnchanged, nil
+	case p.Conflict == ConflictSidecar || diff.IsBinary(existing) || diff.IsBinary(f.Content):
+		f.Path += SidecarSuffix
+		return &f, StatusSidecar, nil
+	default:
+		var conflict bool
+		f.Content, conflict = diff.Merge(base, existing, f.Content, diff.Labels{
+			Ours:   f.Path,
+			Base:   "base",
+			Theirs: "template",
+		})
+		if conflict {
+			status = StatusConflict
+		}
+	}
+	if bytes.Equal(existing, f.Content) {
+		return nil, StatusUnchanged, nil
+	}
+	return &f, status, nil
+}
+
+// resolveMissing resolves a rendered file missing from DirPath. A file of
+// Base was deleted from the project: it stays deleted when the template did
+// not change it, and the template version is written next to it with
+// SidecarSuffix when it did.
+func (p *Parser) resolveMissing(f File) (*File, string, error) {
+	if p.Base == nil {
+		return &f, StatusCreate, nil
+	}
+	base, err := fs.ReadFile(p.Base, f.Path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return &f, StatusCreate, nil
+	case err != nil:
+		return nil, "", err
+	case bytes.Equal(base, f.Content):
+		return nil, StatusUnchanged, nil
+	}
+	f.Path += SidecarSuffix
+	return &f, StatusSidecar, nil
+}
+
+// mergeMod adds the requirements, tools and go version of the template
+// go.mod to the existing go.mod. Requirements are upgraded to the template
+// version when it is newer.
+func mergeMod(existing, template []byte) ([]byte, error) {
+	file, err := modfile.Parse("go.mod", existing, nil)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := modfile.Parse("template go.mod", template, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if tmpl.Go != nil && (file.Go == nil || version.Compare("go"+tmpl.Go.Version, "go"+file.Go.Version) > 0) {
+		if err := file.AddGoStmt(tmpl.Go.Version); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range tmpl.Require {
+		i := slices.IndexFunc(file.Require, func(e *modfile.Require) bool { return e.Mod.Path == r.Mod.Path })
+		switch {
+		case i < 0:
+			file.AddNewRequire(r.Mod.Path, r.Mod.Version, r.Indirect)
+		case semver.Compare(r.Mod.Version, file.Require[i].Mod.Version) > 0:
+			if err := file.AddRequire(r.Mod.Path, r.Mod.Version); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, t := range tmpl.Tool {
+		if err := file.AddTool(t.Path); err != nil {
+			return nil, err
+		}
+	}
+	file.Cleanup()
+	return modfile.Format(file.Syntax), nil
+}
+
+// mergeSum returns the lines of both go.sum files.
+func mergeSum(existing, template []byte) []byte {
+	lines := slices.Concat(diff.Lines(existing), diff.Lines(template))
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\n")
+	}
+	slices.Sort(lines)
+	lines = slices.Compact(lines)
+	lines = slices.DeleteFunc(lines, func(l string) bool { return l == "" })
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
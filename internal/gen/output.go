@@ -0,0 +1,29 @@
+package gen
+
+import (
+	"io/fs"
+	"os"
+)
+
+// Output receives the files generated by Parse, see Parser.Output.
+type Output interface {
+	fs.FS
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Remove(name string) error
+}
+
+// writer is the part of Output used to write files, also implemented by
+// the staging of files for DirPath.
+type writer interface {
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Remove(name string) error
+}
+
+// existing returns the files generated files are compared with, Output or
+// DirPath.
+func (p *Parser) existing() fs.FS {
+	if p.Output != nil {
+		return p.Output
+	}
+	return os.DirFS(p.DirPath)
+}
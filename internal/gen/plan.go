@@ -0,0 +1,88 @@
+package gen
+
+import (
+	"path/filepath"
+)
+
+// Status of a planned file compared to the file already in DirPath.
+const (
+	StatusCreate    = "create"
+	StatusConflict  = "conflict"
+	StatusIdentical = "identical"
+)
+
+// Plan describes what Parse would generate, without writing anything.
+type Plan struct {
+	Dir            string            `json:"dir"`
+	Module         string            `json:"module"`
+	TemplateModule string            `json:"templateModule"`
+	Merge          bool              `json:"merge,omitempty"`
+	Vars           map[string]string `json:"vars,omitempty"`
+	Features       []string          `json:"features,omitempty"`
+	Hooks          []string          `json:"hooks,omitempty"`
+	Files          []PlanFile        `json:"files"`
+}
+
+type PlanFile struct {
+	Path    string   `json:"path"`
+	Size    int      `json:"size"`
+	Status  string   `json:"status"`
+	Rewrite string   `json:"rewrite,omitempty"`
+	Vars    []string `json:"vars,omitempty"`
+}
+
+// Conflicts returns the planned files that differ from existing files.
+func (p *Plan) Conflicts() []PlanFile {
+	var conflicts []PlanFile
+	for _, f := range p.Files {
+		if f.Status == StatusConflict {
+			conflicts = append(conflicts, f)
+		}
+	}
+	return conflicts
+}
+
+// Plan renders the template and compares every file with DirPath.
+func (p *Parser) Plan() (*Plan, error) {
+	dir, err := filepath.Abs(p.DirPath)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{
+		Dir:            dir,
+		Module:         p.NewModName,
+		TemplateModule: p.CurrentModName,
+		Merge:          p.Merge,
+		Vars:           p.Vars,
+		Features:       p.selectedFeatures(),
+	}
+	// hooks are only run in new projects
+	for _, h := range p.Config.Hooks {
+		if !p.Merge {
+			plan.Hooks = append(plan.Hooks, h.Name)
+		}
+	}
+	err = p.Render(func(f File) error {
+		pf := PlanFile{
+			Path:    f.Path,
+			Size:    len(f.Content),
+			Rewrite: f.Rewrite,
+			Vars:    f.Vars,
+		}
+		out, status, err := p.resolve(f)
+		if err != nil {
+			return err
+		}
+		if out != nil {
+			pf.Path = out.Path
+			pf.Size = len(out.Content)
+		}
+		pf.Status = status
+		plan.Files = append(plan.Files, pf)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
@@ -0,0 +1,174 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/gofs-cli/gofs/internal/vscode"
+)
+
+// RewriteManifest is the update of the module in ManifestFile by Rename.
+const RewriteManifest = "gofs manifest"
+
+// renameSkipDirs are not searched by Rename. GofsDir holds the template
+// snapshot, which keeps the template module path.
+var renameSkipDirs = map[string]bool{
+	".git":  true,
+	GofsDir: true,
+}
+
+// renameSkipRootDirs are not searched by Rename at the root of the project
+// only, packages such as internal/tmp are renamed.
+var renameSkipRootDirs = map[string]bool{
+	"node_modules": true,
+	"tmp":          true,
+	"bin":          true,
+}
+
+// RenameFiles returns the files of the project at DirPath with the module
+// path rewritten from CurrentModName to NewModName, without writing them.
+// Only the changed files are returned.
+func (p *Parser) RenameFiles() ([]File, error) {
+	var changed []File
+	err := fs.WalkDir(p.existing(), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name != "." && (renameSkipDirs[d.Name()] || renameSkipRootDirs[name]) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		b, err := fs.ReadFile(p.existing(), name)
+		if err != nil {
+			return err
+		}
+		if !bytes.Contains(b, []byte(p.CurrentModName)) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		f := File{Path: name, Mode: info.Mode().Perm()}
+		switch {
+		case name == "go.mod":
+			if modfile.ModulePath(b) == p.CurrentModName {
+				f.Content, err = p.updateMod(name, b, p.NewModName, &pruning{})
+				f.Rewrite = RewriteMod
+			}
+		case strings.HasSuffix(name, ".go"):
+			f.Content, err = p.updateFile(b, p.CurrentModName, p.NewModName, &pruning{})
+			f.Rewrite = RewriteGo
+		case strings.HasSuffix(name, ".templ"):
+			f.Content, err = p.updateTempl(b)
+			f.Rewrite = RewriteTempl
+		case name == ".vscode/settings.json":
+			f.Content, err = p.renameVscodeSettings(b)
+			f.Rewrite = RewriteVscode
+		case isText(name):
+			f.Content, _ = replaceModule(b, p.CurrentModName, p.NewModName)
+			f.Rewrite = RewriteText
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if f.Content != nil && !bytes.Equal(f.Content, b) {
+			changed = append(changed, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := p.renameManifest(changed)
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil {
+		changed = append(changed, *manifest)
+	}
+	return changed, nil
+}
+
+// Rename rewrites the module path of the project at DirPath in place, see
+// RenameFiles. The files are only written once all of them are rewritten.
+func (p *Parser) Rename() ([]File, error) {
+	changed, err := p.RenameFiles()
+	if err != nil {
+		return nil, err
+	}
+	s, err := newStaging(p.DirPath)
+	if err != nil {
+		return nil, err
+	}
+	defer s.cleanup()
+	for _, f := range changed {
+		if err := s.WriteFile(f.Path, f.Content, f.Mode); err != nil {
+			return nil, err
+		}
+	}
+	return changed, s.commit()
+}
+
+// renameVscodeSettings replaces the module in the gopls formatting.local
+// setting, leaving the other settings as they are.
+func (p *Parser) renameVscodeSettings(b []byte) ([]byte, error) {
+	set := vscode.Settings{}
+	if err := vscode.Unmarshal(b, &set); err != nil {
+		return nil, err
+	}
+	if !set.ReplaceGoplsLocal(p.CurrentModName, p.NewModName) {
+		return b, nil
+	}
+	return vscode.Marshal(set)
+}
+
+// renameManifest returns ManifestFile with the new module. The hashes of
+// renamed files unchanged since generation are updated, so they are not
+// reported as modified. It returns nil without a manifest.
+func (p *Parser) renameManifest(changed []File) (*File, error) {
+	b, err := fs.ReadFile(p.existing(), ManifestFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", ManifestFile, err)
+	}
+	m.Module = p.NewModName
+	for _, f := range changed {
+		old, err := fs.ReadFile(p.existing(), f.Path)
+		if err != nil {
+			return nil, err
+		}
+		if m.Files[f.Path] == Hash(old) {
+			m.Files[f.Path] = Hash(f.Content)
+		}
+	}
+	out, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &File{
+		Path:    ManifestFile,
+		Content: append(out, '\n'),
+		Mode:    fileMode,
+		Rewrite: RewriteManifest,
+	}, nil
+}
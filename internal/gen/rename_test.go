@@ -0,0 +1,81 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRename(t *testing.T) {
+	dir, _ := generate(t, map[string]string{
+		"main.go":               "package main\n\nimport _ \"" + templateModule + "/internal/tmp\"\n",
+		"internal/tmp/tmp.go":   "package tmp\n\nimport _ \"" + templateModule + "/ui\"\n",
+		"ui/page.templ":         "package ui\n\nimport \"" + templateModule + "/internal/tmp\"\n\ntempl Page() {\n\t<p>{ tmp.Name }</p>\n}\n",
+		".vscode/settings.json": "{\n  \"gopls\": {\"formatting.local\": \"" + templateModule + "\"}\n}\n",
+		"README.md":             "go install " + templateModule + "@latest\n",
+		"docs/usage.md":         "import " + templateModule + "\n",
+	})
+	for _, name := range []string{"node_modules/x/a.md", "tmp/notes.md"} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("example.com/app\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// edited after generation, the manifest keeps the generated hash
+	edited := "go install example.com/app@latest\n\nEdited.\n"
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	before, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Parser{DirPath: dir, CurrentModName: "example.com/app", NewModName: "example.com/renamed"}
+	changed, err := p.Rename()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range changed {
+		names = append(names, f.Path)
+	}
+	slices.Sort(names)
+	want := []string{".gofs/manifest.json", ".vscode/settings.json", "README.md", "docs/usage.md", "go.mod", "internal/tmp/tmp.go", "main.go", "ui/page.templ"}
+	if !slices.Equal(names, want) {
+		t.Errorf("changed files = %v, want %v", names, want)
+	}
+
+	files := readDir(t, dir)
+	for _, name := range want {
+		if strings.Contains(files[name], "example.com/app") {
+			t.Errorf("%s not renamed:\n%s", name, files[name])
+		}
+	}
+	for _, name := range []string{"node_modules/x/a.md", "tmp/notes.md"} {
+		if files[name] != "example.com/app\n" {
+			t.Errorf("%s = %q, want it left as is", name, files[name])
+		}
+	}
+
+	after, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Module != "example.com/renamed" {
+		t.Errorf("manifest module = %q, want example.com/renamed", after.Module)
+	}
+	for _, name := range []string{"main.go", "internal/tmp/tmp.go", "docs/usage.md"} {
+		if after.Files[name] != Hash([]byte(files[name])) {
+			t.Errorf("manifest hash of %s not updated", name)
+		}
+	}
+	if after.Files["README.md"] != before.Files["README.md"] {
+		t.Error("manifest hash of the edited README.md changed")
+	}
+}
@@ -0,0 +1,188 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// staging collects generated files in a temporary directory and moves them
+// into the target directory once all of them were written, so a failed
+// generation does not leave a partial project behind.
+type staging struct {
+	// dir is the target directory.
+	dir string
+	// tmp holds the staged files in new and the files replaced by commit in
+	// old, it is removed by cleanup.
+	tmp string
+	// create is set when dir does not exist, new is then renamed to dir.
+	create bool
+	// parent is the outermost directory created for dir, removed by cleanup
+	// unless committed.
+	parent string
+	files  []string
+	// removes are files of dir removed by commit.
+	removes   []string
+	committed bool
+}
+
+// newStaging creates the staging directory for dir. It is created in dir,
+// or next to dir when dir does not exist, to rename files within a single
+// file system.
+func newStaging(dir string) (*staging, error) {
+	s := &staging{dir: dir}
+	parent := dir
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		s.create = true
+		parent = filepath.Dir(dir)
+		s.parent = missingDir(parent)
+		if err := os.MkdirAll(parent, dirMode); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp(parent, ".gofs-staging-")
+	if err != nil {
+		s.cleanup()
+		return nil, err
+	}
+	s.tmp = tmp
+	return s, nil
+}
+
+func (s *staging) newPath(name string) string {
+	return filepath.Join(s.tmp, "new", filepath.FromSlash(name))
+}
+
+func (s *staging) oldPath(name string) string {
+	return filepath.Join(s.tmp, "old", filepath.FromSlash(name))
+}
+
+// WriteFile stages the file. Errors name the file instead of the staging
+// path.
+func (s *staging) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	dst := s.newPath(name)
+	// staged files are new, so perm is reduced by the umask like the
+	// permissions of the directories
+	err := os.MkdirAll(filepath.Dir(dst), dirMode)
+	if err == nil {
+		err = os.WriteFile(dst, data, perm)
+	}
+	if err != nil {
+		var pe *fs.PathError
+		if errors.As(err, &pe) {
+			return fmt.Errorf("%s %s: %w", pe.Op, name, pe.Err)
+		}
+		return err
+	}
+	s.files = append(s.files, name)
+	return nil
+}
+
+// Remove stages the removal of a file of dir.
+func (s *staging) Remove(name string) error {
+	s.removes = append(s.removes, name)
+	return nil
+}
+
+// commit moves the staged files into dir. Existing files are replaced, and
+// restored if a file cannot be moved.
+func (s *staging) commit() error {
+	if s.create {
+		if err := os.MkdirAll(s.newPath("."), dirMode); err != nil {
+			return err
+		}
+		if err := os.Rename(s.newPath("."), s.dir); err != nil {
+			return err
+		}
+		s.committed = true
+		return nil
+	}
+
+	for _, name := range s.files {
+		fi, err := os.Lstat(filepath.Join(s.dir, filepath.FromSlash(name)))
+		if err == nil && fi.IsDir() {
+			return fmt.Errorf("%s: is a directory in %s", name, s.dir)
+		}
+	}
+
+	var moved, replaced, created []string
+	rollback := func(err error) error {
+		for _, name := range slices.Backward(moved) {
+			os.Remove(filepath.Join(s.dir, filepath.FromSlash(name)))
+		}
+		for _, name := range slices.Backward(replaced) {
+			os.Rename(s.oldPath(name), filepath.Join(s.dir, filepath.FromSlash(name)))
+		}
+		for _, dir := range slices.Backward(created) {
+			os.RemoveAll(dir)
+		}
+		return err
+	}
+	for _, name := range s.removes {
+		if err := os.MkdirAll(filepath.Dir(s.oldPath(name)), dirMode); err != nil {
+			return rollback(err)
+		}
+		if err := os.Rename(filepath.Join(s.dir, filepath.FromSlash(name)), s.oldPath(name)); err != nil {
+			return rollback(err)
+		}
+		replaced = append(replaced, name)
+	}
+	for _, name := range s.files {
+		dst := filepath.Join(s.dir, filepath.FromSlash(name))
+		if _, err := os.Lstat(dst); err == nil {
+			if err := os.MkdirAll(filepath.Dir(s.oldPath(name)), dirMode); err != nil {
+				return rollback(err)
+			}
+			if err := os.Rename(dst, s.oldPath(name)); err != nil {
+				return rollback(err)
+			}
+			replaced = append(replaced, name)
+		}
+		if dir := missingDir(filepath.Dir(dst)); dir != "" {
+			if err := os.MkdirAll(filepath.Dir(dst), dirMode); err != nil {
+				return rollback(err)
+			}
+			created = append(created, dir)
+		}
+		if err := os.Rename(s.newPath(name), dst); err != nil {
+			return rollback(err)
+		}
+		moved = append(moved, name)
+	}
+	s.committed = true
+	return nil
+}
+
+// cleanup removes the staging directory, and the directories created for
+// dir when the files were not committed.
+func (s *staging) cleanup() error {
+	if s.parent != "" && !s.committed {
+		return os.RemoveAll(s.parent)
+	}
+	if s.tmp == "" {
+		return nil
+	}
+	return os.RemoveAll(s.tmp)
+}
+
+// missingDir returns the outermost directory of dir that does not exist, or
+// "" if dir exists.
+func missingDir(dir string) string {
+	missing := ""
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return missing
+		}
+		missing = dir
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return missing
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,179 @@
+package gen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+const templateModule = "github.com/org/template"
+
+func stagingTemplate(files map[string]string) fstest.MapFS {
+	template := fstest.MapFS{
+		"go.mod": {Data: []byte("module " + templateModule + "\n\ngo 1.24\n")},
+	}
+	for name, content := range files {
+		template[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return template
+}
+
+// assertNoStaging fails when a staging directory was left in dir.
+func assertNoStaging(t *testing.T, dir string) {
+	t.Helper()
+	left, err := filepath.Glob(filepath.Join(dir, ".gofs-staging-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) > 0 {
+		t.Errorf("staging directories left behind: %v", left)
+	}
+}
+
+// readDir returns the content of the files in dir by slash separated path.
+func readDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(name string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(b)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func assertFiles(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	got := readDir(t, dir)
+	if len(got) != len(want) {
+		t.Errorf("files in %s = %v, want %v", dir, got, want)
+	}
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("%s = %q, want %q", name, got[name], content)
+		}
+	}
+}
+
+var brokenTempl = map[string]string{
+	"a.go":           "package main\n",
+	"z/broken.templ": "package z\n\ntempl Broken( {\n",
+}
+
+func TestParseFailureNewDir(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "nested", "app")
+	p, err := NewParser(dir, templateModule, "example.com/app", stagingTemplate(brokenTempl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse(); err == nil {
+		t.Fatal("Parse succeeded with a broken templ file")
+	}
+	if _, err := os.Stat(filepath.Join(parent, "nested")); !os.IsNotExist(err) {
+		t.Errorf("directory created for the project was left behind: %v", err)
+	}
+	assertNoStaging(t, parent)
+}
+
+func TestParseFailureExistingDir(t *testing.T) {
+	dir := t.TempDir()
+	existing := map[string]string{"a.go": "package existing\n", "keep.txt": "keep\n"}
+	for name, content := range existing {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := NewParser(dir, templateModule, "example.com/app", stagingTemplate(brokenTempl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse(); err == nil {
+		t.Fatal("Parse succeeded with a broken templ file")
+	}
+	assertFiles(t, dir, existing)
+	assertNoStaging(t, dir)
+}
+
+func TestParseContextCanceled(t *testing.T) {
+	dir := t.TempDir()
+	p, err := NewParser(dir, templateModule, "example.com/app", stagingTemplate(map[string]string{"a.go": "package main\n"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.ParseContext(ctx); err == nil {
+		t.Fatal("ParseContext succeeded with a canceled context")
+	}
+	assertFiles(t, dir, map[string]string{})
+	assertNoStaging(t, dir)
+}
+
+func TestCommitRollback(t *testing.T) {
+	dir := t.TempDir()
+	existing := map[string]string{"0.txt": "old\n", "removed.txt": "removed\n"}
+	for name, content := range existing {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := newStaging(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"0.txt", "sub/new.txt", "z.txt"} {
+		if err := s.WriteFile(name, []byte("new\n"), fileMode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Remove("removed.txt"); err != nil {
+		t.Fatal(err)
+	}
+	// the last file cannot be moved, after the others were
+	if err := os.Remove(s.newPath("z.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.commit(); err == nil {
+		t.Fatal("commit succeeded with a missing staged file")
+	}
+	if err := s.cleanup(); err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, dir, existing)
+	if _, err := os.Stat(filepath.Join(dir, "sub")); !os.IsNotExist(err) {
+		t.Errorf("directory created by commit was left behind: %v", err)
+	}
+	assertNoStaging(t, dir)
+}
+
+func TestParseCommit(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app")
+	p, err := NewParser(dir, templateModule, "example.com/app", stagingTemplate(map[string]string{"a.txt": "a\n"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.24\n",
+		"a.txt":  "a\n",
+	})
+	assertNoStaging(t, filepath.Dir(dir))
+}
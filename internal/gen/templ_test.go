@@ -0,0 +1,51 @@
+package gen
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestUpdateTempl(t *testing.T) {
+	p := &Parser{
+		CurrentModName: "github.com/org/template",
+		NewModName:     "example.com/app",
+	}
+	inputs, err := filepath.Glob(filepath.Join("testdata", "templ", "*.templ"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no test files")
+	}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".templ")
+		t.Run(name, func(t *testing.T) {
+			b, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.updateTempl(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := strings.TrimSuffix(input, ".templ") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("updateTempl(%s) =\n%s\nwant\n%s", input, got, want)
+			}
+		})
+	}
+}
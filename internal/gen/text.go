@@ -0,0 +1,86 @@
+package gen
+
+import (
+	"bytes"
+	"path"
+	"strings"
+)
+
+// isText reports whether the module path is rewritten in the file as text,
+// see RewriteText. These are Dockerfiles, Makefiles, YAML and TOML
+// configuration, e.g. sqlc.yaml, .golangci.yml and GitHub workflows, and
+// Markdown.
+func isText(name string) bool {
+	base := path.Base(name)
+	switch {
+	case base == "Dockerfile", strings.HasPrefix(base, "Dockerfile."), strings.HasSuffix(base, ".dockerfile"):
+		return true
+	case base == "Makefile", base == "GNUmakefile", base == "makefile":
+		return true
+	}
+	switch path.Ext(base) {
+	case ".mk", ".yml", ".yaml", ".toml", ".md":
+		return true
+	}
+	return false
+}
+
+// replaceModule replaces the module path old with new where it is not part
+// of a longer path element, e.g. github.com/org/app is replaced in
+// github.com/org/app/internal but not in github.com/org/app2 or
+// github.com/org/app.v2. A dot not followed by a letter or digit ends the
+// path, so the module is replaced at the end of a sentence. The replacement
+// is textual: it applies in comments, strings and URLs alike, e.g. to a link
+// to the template repository in Markdown. It reports whether anything was
+// replaced.
+func replaceModule(b []byte, old, new string) ([]byte, bool) {
+	if old == "" || old == new {
+		return b, false
+	}
+	var out bytes.Buffer
+	replaced := false
+	rest := b
+	for {
+		i := bytes.Index(rest, []byte(old))
+		if i < 0 {
+			break
+		}
+		end := i + len(old)
+		if (i == 0 || !isPathChar(rest[i-1])) && endsPath(rest[end:]) {
+			out.Write(rest[:i])
+			out.WriteString(new)
+			replaced = true
+		} else {
+			out.Write(rest[:end])
+		}
+		rest = rest[end:]
+	}
+	if !replaced {
+		return b, false
+	}
+	out.Write(rest)
+	return out.Bytes(), true
+}
+
+// endsPath reports whether a module path followed by rest ends there: rest
+// is empty, starts with a character that cannot be part of the path, or
+// with a dot that is punctuation rather than part of a path element.
+func endsPath(rest []byte) bool {
+	switch {
+	case len(rest) == 0:
+		return true
+	case rest[0] == '.':
+		return len(rest) == 1 || !isAlnum(rest[1])
+	}
+	return !isPathChar(rest[0])
+}
+
+// isPathChar reports whether c can be part of a module path element. A
+// slash separates elements, so it is not one.
+func isPathChar(c byte) bool {
+	return isAlnum(c) || c == '.' || c == '-' || c == '_' || c == '~'
+}
+
+func isAlnum(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
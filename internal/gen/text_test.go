@@ -0,0 +1,113 @@
+package gen
+
+import (
+	"testing"
+)
+
+func TestIsText(t *testing.T) {
+	tests := map[string]bool{
+		"Dockerfile":                  true,
+		"docker/Dockerfile.dev":       true,
+		"build/app.dockerfile":        true,
+		"Makefile":                    true,
+		"GNUmakefile":                 true,
+		"scripts/rules.mk":            true,
+		".golangci.yml":               true,
+		"sqlc.yaml":                   true,
+		".github/workflows/ci.yml":    true,
+		".air.toml":                   true,
+		"README.md":                   true,
+		"main.go":                     false,
+		"static/app.js":               false,
+		"Makefile.bak":                false,
+		"internal/ui/index_templ.txt": false,
+	}
+	for name, want := range tests {
+		if got := isText(name); got != want {
+			t.Errorf("isText(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// TestRenderText renders text files of every format through Render, so the
+// file type dispatch is covered along with the path boundaries.
+func TestRenderText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "Dockerfile",
+			in: "RUN go build -ldflags \"-X github.com/org/template/internal/version.Version=${VERSION}\" ./cmd/app\n" +
+				"LABEL org.opencontainers.image.source=https://github.com/org/template\n",
+			want: "RUN go build -ldflags \"-X example.com/app/internal/version.Version=${VERSION}\" ./cmd/app\n" +
+				"LABEL org.opencontainers.image.source=https://example.com/app\n",
+		},
+		{
+			name: "Makefile",
+			in:   "MODULE := github.com/org/template\n\ntest:\n\tgo test github.com/org/template/...\n",
+			want: "MODULE := example.com/app\n\ntest:\n\tgo test example.com/app/...\n",
+		},
+		{
+			name: ".golangci.yml",
+			in:   "formatters:\n  settings:\n    goimports:\n      local-prefixes:\n        - github.com/org/template\n",
+			want: "formatters:\n  settings:\n    goimports:\n      local-prefixes:\n        - example.com/app\n",
+		},
+		{
+			name: "sqlc.yaml",
+			in:   "overrides:\n  - go_type: \"github.com/org/template/internal/db.ID\"\n  - go_type: \"github.com/org/template2/db.ID\"\n",
+			want: "overrides:\n  - go_type: \"example.com/app/internal/db.ID\"\n  - go_type: \"github.com/org/template2/db.ID\"\n",
+		},
+		{
+			name: ".air.toml",
+			in:   "[build]\n  cmd = 'go build -o ./tmp/main github.com/org/template/cmd/app'\n",
+			want: "[build]\n  cmd = 'go build -o ./tmp/main example.com/app/cmd/app'\n",
+		},
+		{
+			name: "README.md",
+			in: "# github.com/org/template\n\n" +
+				"Install with `go install github.com/org/template/cmd/app@latest`.\n" +
+				"This is github.com/org/template. Not github.com/org/template.v2, github.com/org/templates or x.github.com/org/template.\n" +
+				"See [the docs](https://pkg.go.dev/github.com/org/template), (github.com/org/template) and github.com/org/template...\n",
+			want: "# example.com/app\n\n" +
+				"Install with `go install example.com/app/cmd/app@latest`.\n" +
+				"This is example.com/app. Not github.com/org/template.v2, github.com/org/templates or x.github.com/org/template.\n" +
+				"See [the docs](https://pkg.go.dev/example.com/app), (example.com/app) and example.com/app...\n",
+		},
+		{
+			name: "config.json",
+			in:   "{\"module\": \"github.com/org/template\"}\n",
+			want: "{\"module\": \"github.com/org/template\"}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Parser{
+				CurrentModName: templateModule,
+				NewModName:     "example.com/app",
+				Template:       stagingTemplate(map[string]string{tt.name: tt.in}),
+				TemplateRoot:   ".",
+			}
+			var got *File
+			err := p.Render(func(f File) error {
+				if f.Path == tt.name {
+					got = &f
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == nil {
+				t.Fatalf("%s not rendered", tt.name)
+			}
+			if string(got.Content) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got.Content, tt.want)
+			}
+			if rewritten := got.Rewrite == RewriteText; rewritten != (tt.in != tt.want) {
+				t.Errorf("Rewrite = %q", got.Rewrite)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package gen
+
+import (
+	"io/fs"
+	"slices"
+)
+
+// NewProjectParser returns a parser generating template with the module,
+// variables, features, excluded files and editors recorded in the manifest
+// of the project at dir. Variables and features the template no longer
+// declares are dropped, and default features added to the template since
+// are selected.
+func NewProjectParser(dir, module string, m *Manifest, template fs.FS, templateModule string) (*Parser, error) {
+	config, err := LoadConfig(template)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := Snapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+	oldConfig, err := LoadConfig(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for name, value := range m.Vars {
+		if _, ok := config.Variable(name); ok {
+			values[name] = value
+		}
+	}
+	vars, err := config.Resolve(values, BuiltinVars(module))
+	if err != nil {
+		return nil, err
+	}
+	features := []string{}
+	for _, f := range config.Features {
+		_, existed := oldConfig.Feature(f.Name)
+		if slices.Contains(m.Features, f.Name) || (f.Default && !existed) {
+			features = append(features, f.Name)
+		}
+	}
+
+	return &Parser{
+		DirPath:        dir,
+		CurrentModName: templateModule,
+		NewModName:     module,
+		Template:       template,
+		TemplateRoot:   ".",
+		Config:         config,
+		Vars:           vars,
+		Features:       features,
+		Exclude:        m.Exclude,
+		Editors:        m.Editors,
+		Conflict:       ConflictMarkers,
+	}, nil
+}
+
+// NewUpgradeParser returns a parser merging the changes between the template
+// snapshot of the project at dir and template into the project, see
+// NewProjectParser. The base of the merge is the snapshot generated with the
+// options recorded in the manifest, so only changes to the template are
+// merged.
+func NewUpgradeParser(dir, module string, m *Manifest, template fs.FS, templateModule string) (*Parser, error) {
+	snapshot, err := Snapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+	oldConfig, err := LoadConfig(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	old := &Parser{
+		DirPath:        dir,
+		CurrentModName: m.TemplateModule,
+		NewModName:     module,
+		Template:       snapshot,
+		TemplateRoot:   ".",
+		Config:         oldConfig,
+		Vars:           m.Vars,
+		Features:       m.Features,
+		Exclude:        m.Exclude,
+		Editors:        m.Editors,
+		Output:         NewMemFS(),
+	}
+	if _, err := old.Parse(); err != nil {
+		return nil, err
+	}
+
+	p, err := NewProjectParser(dir, module, m, template, templateModule)
+	if err != nil {
+		return nil, err
+	}
+	manifest := *m
+	p.Merge = true
+	p.Base = old.Output
+	p.Manifest = &manifest
+	return p, nil
+}
+
+// Removed returns the files of Base the template no longer generates. They
+// are left in DirPath.
+func (p *Parser) Removed() ([]string, error) {
+	if p.Base == nil {
+		return nil, nil
+	}
+	rendered := map[string]bool{}
+	err := p.Render(func(f File) error {
+		rendered[f.Path] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	err = fs.WalkDir(p.Base, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if !rendered[name] {
+			removed = append(removed, name)
+		}
+		return nil
+	})
+	return removed, err
+}
@@ -0,0 +1,122 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// generate generates the template into a new project and returns its
+// directory and manifest.
+func generate(t *testing.T, files map[string]string) (string, *Manifest) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "app")
+	p, err := NewParser(dir, templateModule, "example.com/app", stagingTemplate(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Manifest = &Manifest{Template: "test"}
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, m
+}
+
+func TestUpgrade(t *testing.T) {
+	tests := []struct {
+		name     string
+		template map[string]string
+		deleted  string
+		want     map[string]string
+		results  []Result
+	}{
+		{
+			name:     "only template changed",
+			template: map[string]string{"a.txt": "a2\n", "b.txt": "b\n"},
+			want:     map[string]string{"a.txt": "a2\n", "b.txt": "b\n"},
+			results:  []Result{{Path: "a.txt", Status: StatusMerged}},
+		},
+		{
+			name:     "deleted in project, unchanged in template",
+			template: map[string]string{"a.txt": "a\n", "b.txt": "b\n"},
+			deleted:  "a.txt",
+			want:     map[string]string{"b.txt": "b\n"},
+			results:  []Result{{Path: "a.txt", Status: StatusUnchanged}},
+		},
+		{
+			name:     "deleted in project, changed in template",
+			template: map[string]string{"a.txt": "a2\n", "b.txt": "b\n"},
+			deleted:  "a.txt",
+			want:     map[string]string{"a.txt" + SidecarSuffix: "a2\n", "b.txt": "b\n"},
+			results:  []Result{{Path: "a.txt" + SidecarSuffix, Status: StatusSidecar}},
+		},
+		{
+			name:     "added to template",
+			template: map[string]string{"a.txt": "a\n", "b.txt": "b\n", "c.txt": "c\n"},
+			want:     map[string]string{"a.txt": "a\n", "b.txt": "b\n", "c.txt": "c\n"},
+			results:  []Result{{Path: "c.txt", Status: StatusCreate}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, m := generate(t, map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+			if tt.deleted != "" {
+				if err := os.Remove(filepath.Join(dir, tt.deleted)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p, err := NewUpgradeParser(dir, "example.com/app", m, stagingTemplate(tt.template), templateModule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			plan, err := p.Plan()
+			if err != nil {
+				t.Fatal(err)
+			}
+			results, err := p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.results {
+				if !slices.Contains(results, want) {
+					t.Errorf("results %v do not contain %v", results, want)
+				}
+				if !slices.ContainsFunc(plan.Files, func(f PlanFile) bool { return f.Path == want.Path && f.Status == want.Status }) {
+					t.Errorf("plan %v does not contain %v", plan.Files, want)
+				}
+			}
+			got := readDir(t, dir)
+			for name, content := range tt.want {
+				if got[name] != content {
+					t.Errorf("%s = %q, want %q", name, got[name], content)
+				}
+			}
+			for _, name := range []string{"a.txt", "a.txt" + SidecarSuffix} {
+				if _, ok := tt.want[name]; !ok && got[name] != "" {
+					t.Errorf("%s written: %q", name, got[name])
+				}
+			}
+		})
+	}
+}
+
+func TestRemoved(t *testing.T) {
+	dir, m := generate(t, map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	p, err := NewUpgradeParser(dir, "example.com/app", m, stagingTemplate(map[string]string{"a.txt": "a\n"}), templateModule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, err := p.Removed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(removed, []string{"b.txt"}) {
+		t.Errorf("Removed() = %v, want [b.txt]", removed)
+	}
+}
@@ -0,0 +1,43 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/mod/modfile"
+)
+
+// ErrNotFound is returned when no go.mod is found in the directory or any of
+// its parents.
+var ErrNotFound = errors.New("not inside a go module, no go.mod found")
+
+// Project is a go module on disk, usually generated by gofs.
+type Project struct {
+	// Root is the directory containing the go.mod file.
+	Root string
+	// Module is the module path declared in go.mod.
+	Module string
+}
+
+// Find returns the project containing dir by walking up to the nearest go.mod.
+func Find(dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		b, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			return &Project{Root: dir, Module: modfile.ModulePath(b)}, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, ErrNotFound
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,199 @@
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrAborted is returned when the input ends before a question is answered.
+var ErrAborted = errors.New("aborted")
+
+// Prompter asks questions on out and reads the answers from in.
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func New(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+}
+
+// IsTerminal reports whether r is an interactive terminal.
+func IsTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
+
+func (p *Prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			return "", ErrAborted
+		}
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// String asks for a value. An empty answer selects def, and the question is
+// repeated until validate accepts the answer. validate may be nil.
+func (p *Prompter) String(label, def string, validate func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", label, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", label)
+		}
+		v, err := p.readLine()
+		if err != nil {
+			return "", err
+		}
+		if v == "" {
+			v = def
+		}
+		if v == "" {
+			fmt.Fprintln(p.out, "  a value is required")
+			continue
+		}
+		if validate != nil {
+			if err := validate(v); err != nil {
+				fmt.Fprintf(p.out, "  %s\n", err)
+				continue
+			}
+		}
+		return v, nil
+	}
+}
+
+// Option is a choice of Select.
+type Option struct {
+	Value       string
+	Description string
+}
+
+// Select asks to choose one of the options by number or value. An empty
+// answer selects def.
+func (p *Prompter) Select(label string, options []Option, def string) (string, error) {
+	width := 0
+	defIndex := ""
+	for i, o := range options {
+		width = max(width, len(o.Value))
+		if o.Value == def {
+			defIndex = strconv.Itoa(i + 1)
+		}
+	}
+	fmt.Fprintf(p.out, "%s:\n", label)
+	for i, o := range options {
+		fmt.Fprintf(p.out, "  %d) %-*s  %s\n", i+1, width, o.Value, o.Description)
+	}
+	for {
+		v, err := p.String("Choose", defIndex, nil)
+		if err != nil {
+			return "", err
+		}
+		if n, err := strconv.Atoi(v); err == nil && n >= 1 && n <= len(options) {
+			return options[n-1].Value, nil
+		}
+		for _, o := range options {
+			if o.Value == v {
+				return v, nil
+			}
+		}
+		fmt.Fprintf(p.out, "  choose a number between 1 and %d\n", len(options))
+	}
+}
+
+// MultiSelect asks to choose any of the options by a comma separated list of
+// numbers or values. An empty answer selects defs and "none" selects nothing.
+func (p *Prompter) MultiSelect(label string, options []Option, defs []string) ([]string, error) {
+	width := 0
+	for _, o := range options {
+		width = max(width, len(o.Value))
+	}
+	fmt.Fprintf(p.out, "%s:\n", label)
+	for i, o := range options {
+		mark := " "
+		if slices.Contains(defs, o.Value) {
+			mark = "x"
+		}
+		fmt.Fprintf(p.out, "  %d) [%s] %-*s  %s\n", i+1, mark, width, o.Value, o.Description)
+	}
+	def := strings.Join(defs, ",")
+	if def == "" {
+		def = "none"
+	}
+	for {
+		v, err := p.String("Choose (comma separated)", def, nil)
+		if err != nil {
+			return nil, err
+		}
+		if v == "none" {
+			return []string{}, nil
+		}
+		if chosen, ok := chooseOptions(options, v); ok {
+			return chosen, nil
+		}
+		fmt.Fprintf(p.out, "  choose numbers between 1 and %d or \"none\"\n", len(options))
+	}
+}
+
+// chooseOptions returns the values of the options in the comma separated
+// answer, in option order.
+func chooseOptions(options []Option, answer string) ([]string, bool) {
+	picked := make([]bool, len(options))
+	for _, a := range strings.Split(answer, ",") {
+		a = strings.TrimSpace(a)
+		i := slices.IndexFunc(options, func(o Option) bool { return o.Value == a })
+		if n, err := strconv.Atoi(a); err == nil && n >= 1 && n <= len(options) {
+			i = n - 1
+		}
+		if i < 0 {
+			return nil, false
+		}
+		picked[i] = true
+	}
+	var chosen []string
+	for i, o := range options {
+		if picked[i] {
+			chosen = append(chosen, o.Value)
+		}
+	}
+	return chosen, true
+}
+
+// Confirm asks a yes or no question. An empty answer selects def.
+func (p *Prompter) Confirm(label string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		fmt.Fprintf(p.out, "%s [%s]: ", label, hint)
+		v, err := p.readLine()
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(v) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package prompt
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var options = []Option{
+	{Value: "azure", Description: "Azure app"},
+	{Value: "default", Description: "Bare bones"},
+	{Value: "fs", Description: "Full stack"},
+}
+
+func TestString(t *testing.T) {
+	noSpace := func(s string) error {
+		if strings.Contains(s, " ") {
+			return errors.New("no spaces allowed")
+		}
+		return nil
+	}
+	tests := []struct {
+		name string
+		in   string
+		def  string
+		want string
+		out  string
+	}{
+		{name: "answer", in: "app\n", want: "app", out: "Name: "},
+		{name: "default", in: "\n", def: "app", want: "app", out: "Name [app]: "},
+		{name: "trimmed", in: "  app  \r\n", want: "app"},
+		{name: "no newline", in: "app", want: "app"},
+		{name: "required", in: "\napp\n", want: "app", out: "  a value is required\n"},
+		{name: "invalid", in: "my app\napp\n", want: "app", out: "  no spaces allowed\nName: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			got, err := New(strings.NewReader(tt.in), &out).String("Name", tt.def, noSpace)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if !strings.Contains(out.String(), tt.out) {
+				t.Errorf("output does not contain %q:\n%s", tt.out, out.String())
+			}
+		})
+	}
+}
+
+func TestStringAborted(t *testing.T) {
+	for _, in := range []string{"", "\n"} {
+		_, err := New(strings.NewReader(in), &strings.Builder{}).String("Name", "", nil)
+		if !errors.Is(err, ErrAborted) {
+			t.Errorf("input %q: got error %v, want %v", in, err, ErrAborted)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "3\n", want: "fs"},
+		{in: "azure\n", want: "azure"},
+		{in: "\n", want: "default"},
+		{in: "4\nfs\n", want: "fs"},
+		{in: "vue\n1\n", want: "azure"},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		got, err := New(strings.NewReader(tt.in), &out).Select("Template", options, "default")
+		if err != nil {
+			t.Fatalf("input %q: %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("input %q: got %q, want %q", tt.in, got, tt.want)
+		}
+		if !strings.Contains(out.String(), "  2) default  Bare bones\n") || !strings.Contains(out.String(), "Choose [2]: ") {
+			t.Errorf("input %q: unexpected output:\n%s", tt.in, out.String())
+		}
+	}
+}
+
+func TestMultiSelect(t *testing.T) {
+	tests := []struct {
+		in   string
+		defs []string
+		want []string
+	}{
+		{in: "\n", defs: []string{"azure", "fs"}, want: []string{"azure", "fs"}},
+		{in: "\n", want: []string{}},
+		{in: "none\n", defs: []string{"azure"}, want: []string{}},
+		{in: "3, azure\n", want: []string{"azure", "fs"}},
+		{in: "1,1\n", want: []string{"azure"}},
+		{in: "1,vue\n2\n", want: []string{"default"}},
+	}
+	for _, tt := range tests {
+		got, err := New(strings.NewReader(tt.in), &strings.Builder{}).MultiSelect("Features", options, tt.defs)
+		if err != nil {
+			t.Fatalf("input %q: %v", tt.in, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("input %q: got %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		in   string
+		def  bool
+		want bool
+	}{
+		{in: "\n", def: true, want: true},
+		{in: "\n", def: false, want: false},
+		{in: "y\n", want: true},
+		{in: "YES\n", want: true},
+		{in: "n\n", def: true, want: false},
+		{in: "maybe\nno\n", def: true, want: false},
+	}
+	for _, tt := range tests {
+		got, err := New(strings.NewReader(tt.in), &strings.Builder{}).Confirm("Create project?", tt.def)
+		if err != nil {
+			t.Fatalf("input %q: %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("input %q, default %v: got %v, want %v", tt.in, tt.def, got, tt.want)
+		}
+	}
+}
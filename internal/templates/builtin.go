@@ -0,0 +1,32 @@
+package templates
+
+import (
+	azureTemplate "github.com/gofs-cli/azure-app-template"
+	defaultTemplate "github.com/gofs-cli/template"
+
+	fsTemplate "github.com/gofs-cli/gofs/templates/fs-app"
+)
+
+func init() {
+	Register(Template{
+		Name:        Default,
+		Description: "The basic bare bones template.",
+		FS:          defaultTemplate.Folder,
+		ModuleName:  defaultTemplate.ModuleName,
+		Root:        ".",
+	})
+	Register(Template{
+		Name:        "azure",
+		Description: "An app for deployment to azure apps, expects azure auth tokens from Entra ID.",
+		FS:          azureTemplate.Folder,
+		ModuleName:  azureTemplate.ModuleName,
+		Root:        ".",
+	})
+	Register(Template{
+		Name:        "fs",
+		Description: "A general app using daisyUI/Tailwind/htmx/alpinejs/Go.",
+		FS:          fsTemplate.Folder,
+		ModuleName:  fsTemplate.ModuleName,
+		Root:        ".",
+	})
+}
@@ -0,0 +1,168 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/gofs-cli/gofs/internal/gen"
+)
+
+// Template is a project template that gofs can generate.
+type Template struct {
+	// Name is used to select the template e.g. gofs init -template=<Name>.
+	Name string
+	// Description is shown in the list of templates.
+	Description string
+	// FS holds the template files, usually an embed.FS of the template module.
+	FS fs.FS
+	// ModuleName is the module path of the template, rewritten to the module
+	// path of the generated project.
+	ModuleName string
+	// Root is the directory of the template in FS, "." for the FS root.
+	Root string
+}
+
+// Files returns the template files rooted at Root.
+func (t Template) Files() (fs.FS, error) {
+	if t.Root == "" || t.Root == "." {
+		return t.FS, nil
+	}
+	return fs.Sub(t.FS, t.Root)
+}
+
+// Default is the name of the template used when none is selected.
+const Default = "default"
+
+var registry = map[string]Template{}
+
+// Register adds a template to the registry. It panics if a template with the
+// same name is already registered.
+func Register(t Template) {
+	if t.Name == "" {
+		panic("templates: Register template without a name")
+	}
+	if _, dup := registry[t.Name]; dup {
+		panic("templates: Register called twice for template " + t.Name)
+	}
+	registry[t.Name] = t
+}
+
+// Lookup returns the registered template with the name.
+func Lookup(name string) (Template, error) {
+	t, ok := registry[name]
+	if !ok {
+		return Template{}, fmt.Errorf("unknown template %q, available templates are %s", name, strings.Join(Names(), ", "))
+	}
+	return t, nil
+}
+
+// List returns the registered templates sorted by name.
+func List() []Template {
+	list := make([]Template, 0, len(registry))
+	for _, t := range registry {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Names returns the names of the registered templates, sorted.
+func Names() []string {
+	var names []string
+	for _, t := range List() {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// checkoutIgnore are the files of a template directory left out besides
+// the ones its .gitignore lists: local settings, dependencies and build
+// output that are never part of a template.
+const checkoutIgnore = `.env
+node_modules/
+/tmp/
+/bin/
+`
+
+// FromDir returns a template read from a directory on disk, such as a git
+// checkout of a template module. The module name is read from the go.mod in
+// the directory. Files listed in the .gitignore of the directory, .env
+// files, node_modules and the tmp and bin directories are left out.
+func FromDir(dir string) (Template, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return Template{}, err
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return Template{}, fmt.Errorf("template dir %s: %w", dir, err)
+	}
+	moduleName := modfile.ModulePath(b)
+	if moduleName == "" {
+		return Template{}, fmt.Errorf("template dir %s: no module path in go.mod", dir)
+	}
+	ignore, err := checkoutIgnores(dir)
+	if err != nil {
+		return Template{}, err
+	}
+	return Template{
+		Name:        filepath.Base(dir),
+		Description: "Template from " + dir,
+		FS:          checkoutFS{FS: os.DirFS(dir), ignore: ignore},
+		ModuleName:  moduleName,
+		Root:        ".",
+	}, nil
+}
+
+// checkoutIgnores returns checkoutIgnore followed by the patterns of the
+// .gitignore in dir.
+func checkoutIgnores(dir string) (gen.Ignore, error) {
+	patterns := []byte(checkoutIgnore)
+	b, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	ignore, err := gen.ParseIgnore(append(patterns, b...))
+	if err != nil {
+		return nil, fmt.Errorf("template dir %s: .gitignore: %w", dir, err)
+	}
+	return ignore, nil
+}
+
+// checkoutFS is a template directory without the ignored files.
+type checkoutFS struct {
+	fs.FS
+	ignore gen.Ignore
+}
+
+func (c checkoutFS) Open(name string) (fs.File, error) {
+	if name != "." && c.ignore.Ignores(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return c.FS.Open(name)
+}
+
+func (c checkoutFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." && c.ignore.IgnoresDir(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries, err := fs.ReadDir(c.FS, name)
+	return slices.DeleteFunc(entries, func(e fs.DirEntry) bool {
+		name := path.Join(name, e.Name())
+		if e.IsDir() {
+			return c.ignore.IgnoresDir(name)
+		}
+		return c.ignore.Ignores(name)
+	}), err
+}
@@ -0,0 +1,63 @@
+package templates
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFromDirIgnores(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                      "module example.com/template\n\ngo 1.24\n",
+		".gitignore":                  "logs/\n*.log\n!keep.log\n",
+		".env":                        "SECRET=1\n",
+		".env.example":                "SECRET=\n",
+		"main.go":                     "package main\n",
+		"debug.log":                   "debug\n",
+		"keep.log":                    "keep\n",
+		"logs/today.txt":              "today\n",
+		"node_modules/x/index.js":     "x\n",
+		"web/node_modules/y/index.js": "y\n",
+		"tmp/main":                    "binary\n",
+		"bin/app":                     "binary\n",
+		"internal/tmp/tmp.go":         "package tmp\n",
+		"internal/bin/bin.go":         "package bin\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tmpl, err := FromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	err = fs.WalkDir(tmpl.FS, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		got = append(got, name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".env.example", ".gitignore", "go.mod", "internal/bin/bin.go", "internal/tmp/tmp.go", "keep.log", "main.go"}
+	if !slices.Equal(got, want) {
+		t.Errorf("template files = %v, want %v", got, want)
+	}
+	for _, name := range []string{".env", "debug.log", "node_modules/x/index.js", "tmp/main"} {
+		if _, err := fs.ReadFile(tmpl.FS, name); err == nil {
+			t.Errorf("ignored %s can be read", name)
+		}
+	}
+}
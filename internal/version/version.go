@@ -0,0 +1,106 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Devel is reported when the version is not known, e.g. when built from a
+// local checkout with go build.
+const Devel = "(devel)"
+
+// Info describes the gofs build.
+type Info struct {
+	Version   string   `json:"version"`
+	GoVersion string   `json:"goVersion"`
+	Platform  string   `json:"platform"`
+	Revision  string   `json:"revision,omitempty"`
+	Time      string   `json:"time,omitempty"`
+	Modified  bool     `json:"modified,omitempty"`
+	Templates []Module `json:"templates,omitempty"`
+}
+
+// Module is a dependency compiled into gofs, such as an embedded template.
+type Module struct {
+	// Name is the template name, set by the caller.
+	Name    string `json:"name,omitempty"`
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	// Replace is the replacement path when the module is replaced in go.mod,
+	// e.g. a template that lives in the gofs repository.
+	Replace string `json:"replace,omitempty"`
+}
+
+// Get returns the build info of the running binary. Templates are left for
+// the caller to fill in with Template.
+func Get() Info {
+	info := Info{
+		Version:   Devel,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	if bi.Main.Version != "" {
+		info.Version = bi.Main.Version
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.time":
+			info.Time = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
+
+// Gofs returns the version of the running gofs binary.
+func Gofs() string {
+	return Get().Version
+}
+
+// Template returns the version of a template module compiled into gofs.
+func Template(path string) Module {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return Module{Path: path, Version: Devel}
+	}
+	return module(bi, path)
+}
+
+func module(bi *debug.BuildInfo, path string) Module {
+	// templates in the main module share its version
+	if path == bi.Main.Path {
+		return Module{Path: path, Version: mainVersion(bi)}
+	}
+	for _, dep := range bi.Deps {
+		if dep.Path != path {
+			continue
+		}
+		m := Module{Path: path, Version: dep.Version}
+		if r := dep.Replace; r != nil {
+			m.Replace = r.Path
+			if r.Version != "" && r.Version != Devel {
+				m.Version = r.Version
+			} else {
+				// replaced by a local directory, versioned with gofs
+				m.Version = mainVersion(bi)
+			}
+		}
+		return m
+	}
+	return Module{Path: path, Version: Devel}
+}
+
+func mainVersion(bi *debug.BuildInfo) string {
+	if bi.Main.Version == "" {
+		return Devel
+	}
+	return bi.Main.Version
+}
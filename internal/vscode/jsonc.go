@@ -0,0 +1,78 @@
+package vscode
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Unmarshal decodes a settings file. VS Code settings are JSONC: JSON with
+// // and /* */ comments and trailing commas, which are removed before
+// decoding. Comments are not kept when the file is written with Marshal.
+func Unmarshal(b []byte, v any) error {
+	std, err := Standardize(b)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(std, v)
+}
+
+// Standardize returns the JSONC in b as standard JSON. Comments and trailing
+// commas are replaced with spaces, so offsets in decoding errors still point
+// into b.
+func Standardize(b []byte) ([]byte, error) {
+	out := make([]byte, len(b))
+	copy(out, b)
+	lastComma := -1
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case c == '"':
+			i++
+			for i < len(out) && out[i] != '"' {
+				if out[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(out) {
+				return nil, errors.New("unterminated string")
+			}
+			lastComma = -1
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for i < len(out) && out[i] != '\n' {
+				out[i] = ' '
+				i++
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			start := i
+			i += 2
+			for i+1 < len(out) && (out[i] != '*' || out[i+1] != '/') {
+				i++
+			}
+			if i+1 >= len(out) {
+				return nil, errors.New("unterminated comment")
+			}
+			i++
+			blank(out[start : i+1])
+		case c == ',':
+			lastComma = i
+		case c == '}' || c == ']':
+			if lastComma >= 0 {
+				out[lastComma] = ' '
+			}
+			lastComma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			lastComma = -1
+		}
+	}
+	return out, nil
+}
+
+// blank replaces the bytes of a comment with spaces, keeping line breaks.
+func blank(b []byte) {
+	for i, c := range b {
+		if c != '\n' && c != '\r' {
+			b[i] = ' '
+		}
+	}
+}
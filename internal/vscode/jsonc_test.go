@@ -0,0 +1,89 @@
+package vscode
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStandardize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "json",
+			in:   `{"a": [1, 2], "b": {"c": "d"}}`,
+			want: `{"a": [1, 2], "b": {"c": "d"}}`,
+		},
+		{
+			name: "line comment",
+			in:   "{\n  // gopls\n  \"a\": 1 // one\n}",
+			want: "{\n          \n  \"a\": 1       \n}",
+		},
+		{
+			name: "block comment",
+			in:   "{/* a\nb */\"a\": 1}",
+			want: "{    \n    \"a\": 1}",
+		},
+		{
+			name: "trailing commas",
+			in:   `{"a": [1, 2,], "b": {"c": "d",},}`,
+			want: `{"a": [1, 2 ], "b": {"c": "d" } }`,
+		},
+		{
+			name: "trailing comma before comment",
+			in:   "{\"a\": 1, // last\n}",
+			want: "{\"a\": 1         \n}",
+		},
+		{
+			name: "comment markers in strings",
+			in:   `{"url": "http://example.com/*", "s": "a\",]"}`,
+			want: `{"url": "http://example.com/*", "s": "a\",]"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Standardize([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", got, tt.want)
+			}
+			if !json.Valid(got) {
+				t.Errorf("not valid JSON: %s", got)
+			}
+		})
+	}
+}
+
+func TestStandardizeErrors(t *testing.T) {
+	for _, in := range []string{`{"a": "b}`, `{"a": 1 /* }`} {
+		if _, err := Standardize([]byte(in)); err == nil {
+			t.Errorf("%q: no error", in)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	in := `{
+  // Go
+  "gopls": {
+    "formatting.local": "example.com/app", /* the module */
+  },
+  "files.exclude": ["*_templ.go",],
+}`
+	var got Settings
+	if err := Unmarshal([]byte(in), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := Settings{
+		"gopls":         map[string]any{"formatting.local": "example.com/app"},
+		"files.exclude": []any{"*_templ.go"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -1,13 +1,125 @@
 package vscode
 
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// Settings is .vscode/settings.json.
 type Settings map[string]any
 
-type Gopls struct {
-	FormattingLocal   string   `json:"formatting.local"`
-	FormattingGofumpt bool     `json:"formatting.gofumpt"`
-	BuildBuildFlags   []string `json:"build.buildFlags"`
+// Merge adds the settings of defaults missing from s. Objects are merged key
+// by key, so the settings already in s are kept. It reports whether s
+// changed.
+func (s Settings) Merge(defaults Settings) bool {
+	return mergeObject(s, defaults)
+}
+
+func mergeObject(dst, src map[string]any) bool {
+	changed := false
+	for k, v := range src {
+		existing, ok := dst[k]
+		if !ok {
+			dst[k] = v
+			changed = true
+			continue
+		}
+		d, dok := existing.(map[string]any)
+		s, sok := v.(map[string]any)
+		if dok && sok && mergeObject(d, s) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// SetGoplsLocal sets the import prefixes of the gopls formatting.local
+// setting to the module, keeping the other gopls settings.
+func (s Settings) SetGoplsLocal(module string) {
+	g, ok := s["gopls"].(map[string]any)
+	if !ok {
+		g = map[string]any{}
+		s["gopls"] = g
+	}
+	g["formatting.local"] = module
+}
+
+// AddGoplsLocal adds the module to the comma separated import prefixes of
+// the gopls formatting.local setting, and reports whether the setting
+// changed.
+func (s Settings) AddGoplsLocal(module string) bool {
+	g, ok := s["gopls"].(map[string]any)
+	if !ok {
+		g = map[string]any{}
+		s["gopls"] = g
+	}
+	local, _ := g["formatting.local"].(string)
+	var prefixes []string
+	for _, p := range strings.Split(local, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			prefixes = append(prefixes, p)
+		}
+	}
+	if slices.Contains(prefixes, module) {
+		return false
+	}
+	g["formatting.local"] = strings.Join(append(prefixes, module), ",")
+	return true
+}
+
+// ReplaceGoplsLocal replaces old with new in the comma separated import
+// prefixes of the gopls formatting.local setting, and reports whether the
+// setting changed.
+func (s Settings) ReplaceGoplsLocal(old, new string) bool {
+	g, ok := s["gopls"].(map[string]any)
+	if !ok {
+		return false
+	}
+	local, _ := g["formatting.local"].(string)
+	prefixes := strings.Split(local, ",")
+	changed := false
+	for i, p := range prefixes {
+		if strings.TrimSpace(p) == old {
+			prefixes[i] = new
+			changed = true
+		}
+	}
+	if changed {
+		g["formatting.local"] = strings.Join(prefixes, ",")
+	}
+	return changed
+}
+
+// Extensions is .vscode/extensions.json.
+type Extensions struct {
+	Recommendations []string `json:"recommendations"`
+	Unwanted        []string `json:"unwantedRecommendations,omitempty"`
+}
+
+// Recommend adds the extension ids missing from the recommendations, unless
+// they are unwanted, and reports whether the recommendations changed.
+func (e *Extensions) Recommend(ids ...string) bool {
+	changed := false
+	for _, id := range ids {
+		if !slices.Contains(e.Recommendations, id) && !slices.Contains(e.Unwanted, id) {
+			e.Recommendations = append(e.Recommendations, id)
+			changed = true
+		}
+	}
+	return changed
 }
 
-func (s Settings) SetGopls(g Gopls) {
-	s["gopls"] = g
+// Marshal encodes a settings file the way VS Code writes it, indented with
+// two spaces.
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }